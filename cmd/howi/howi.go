@@ -1,34 +1,37 @@
 package main
 
 import (
-	"time"
-
 	"github.com/digaverse/howi"
 	"github.com/digaverse/howi/lib/cli"
+	"github.com/digaverse/howi/pkg/project"
 )
 
+// Version, build date and commit are injected at build time with -ldflags -X
+// or read from the VCS information embedded by the go toolchain.
+const config = `{
+	"name": "howi",
+	"namespace": "digaverse",
+	"title": "HOWI",
+	"description": "The extreme simplicity of HOWICLI makes the building of CLI applications in go super fun and easy. Includes collection of extended Go standard libraries, replacements, helpers and additional packages to transform HOWI API from it's other language bindings into Go.",
+	"keywords": ["golang-tools", "go", "golang", "golang-library", "howi"],
+	"license": "Apache-2.0",
+	"homepage": "https://github.com/digaverse/howi",
+	"repository": "https://github.com/digaverse/howi",
+	"copyright": {"since": 2005, "by": "Marko Kungla"},
+	"author": "Marko Kungla <marko@digaverse.com>",
+	"contributors": ["Marko Kungla <marko@digaverse.com>"],
+	"config": {"loglevel": 7, "color": "yellow"}
+}`
+
 func main() {
-	h, err := howi.New("howi")
+	prj, err := project.New([]byte(config))
 	if err != nil {
 		panic(err)
 	}
-	// parse .howi.yaml or set defaults
-	appMeta := h.Meta()
-	appMeta.SetNamespace("digaverse")
-	appMeta.SetTitle("HOWI")
-	appMeta.SetShortDesc("The extreme simplicity of HOWICLI makes the building of CLI applications in go super fun and easy. Includes collection of extended Go standard libraries, replacements, helpers and additional packages to transform HOWI API from it's other language bindings into Go.")
-	appMeta.SetKeywords("golang-tools", "go", "golang", "golang-library", "howi")
-	appMeta.SetCopyRight(2005, "Marko Kungla")
-	appMeta.SetVersion("5.0.0-alpha.a.3")
-	appMeta.SetURL("https://github.com/howi-ce/howi")
-
-	appMeta.AddContributor("Marko Kungla <marko@digaverse.com>")
-
-	buildDate, err := time.Parse(time.RFC3339, "2018-03-06T03:06:34+02:00")
+	h, err := howi.New(prj)
 	if err != nil {
-		buildDate = time.Now().UTC()
+		panic(err)
 	}
-	appMeta.SetBuildDate(buildDate)
 
 	// Command-line interface
 	howicli := h.CLI()
@@ -37,11 +40,12 @@ func main() {
 	// Application header
 	howicli.Header.SetTemplate(`
 ################################################################################
-# {{ .Title }}{{ if .CopyRight}}
-#  {{ .CopyRight }}{{end}}
-# {{if .Version}}
-#   Version:    {{ .Version }}{{end}}{{if .BuildDate}}
-#   Build date: {{ .BuildDate | funcDate }}{{end}}
+# {{ .Title }}{{ if .Copyright.Since }}
+#  Copyright © {{ .Copyright.Since }} {{ .Copyright.By }}. All rights reserved.{{end}}
+#
+#   Version:    {{ .Version }}{{if not .BuildDate.IsZero}}
+#   Build date: {{ .BuildDate | funcDate }}{{end}}{{if .Commit}}
+#   Commit:     {{ .Commit }}{{if .Dirty}} (dirty){{end}}{{end}}
 ################################################################################
 `)
 	// Application footer
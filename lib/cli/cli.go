@@ -5,13 +5,18 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/digaverse/howi/lib/cli/flags"
 	"github.com/digaverse/howi/pkg/errors"
 	"github.com/digaverse/howi/pkg/log"
 	"github.com/digaverse/howi/pkg/project"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 const (
@@ -25,6 +30,9 @@ const (
 	StatusRunning
 	// StatusFailed marks that phase failed (804)
 	StatusFailed
+)
+
+const (
 	// FmtErrFlagShadowing formats shadowed flag error.
 	FmtErrFlagShadowing = "flag(%s) alias %q shadows existing flag for %q"
 	// FmtErrCommandFlagShadowing formats command shaddowed flag error.
@@ -48,17 +56,50 @@ const (
 	FmtErrUnknownCommand = "unknown command %q"
 	// FmtErrUnknownFlag formats error for any request looking non existing flag.
 	FmtErrUnknownFlag = "unknown flag %q for command %q"
+	// FmtErrUnknownWorkerFlag formats error for Worker.Flag looking up non
+	// existing flag.
+	FmtErrUnknownWorkerFlag = "unknown flag %q"
 	// FmtErrRequiredFlag formats error if required flag is missing
 	FmtErrRequiredFlag = "%q requires flag %q %q"
 	// FmtErrUnknownSubcommand formats error for unknown subcommand request.
 	FmtErrUnknownSubcommand = "unknown subcommand %q for command %q"
 	// FmtErrTooManyArgs formats error when too many arguments are passed.
 	FmtErrTooManyArgs = "too many arguments for command %q which accepts max (%d) args"
+	// FmtErrInvalidExample formats error for example which fails to parse.
+	FmtErrInvalidExample = "example %q of command %q is invalid: %s"
+	// FmtErrExampleOtherCommand formats error for example invoking other command.
+	FmtErrExampleOtherCommand = "example %q of command %q invokes %q"
+	// FmtErrUnknownSeeAlso formats error for see also reference to unknown command.
+	FmtErrUnknownSeeAlso = "see also %q of command %q refers to unknown command"
+	// FmtErrUnknownHelpTopic formats error for help request of unknown command or topic.
+	FmtErrUnknownHelpTopic = "unknown command or help topic %q, see (%s help topics)"
+	// FmtErrHelpTopicInUse formats error for help topic name already in use.
+	FmtErrHelpTopicInUse = "help topic %q is already in use"
+	// FmtErrPluginInstalled formats error for plugin installed more than once.
+	FmtErrPluginInstalled = "plugin %q is already installed"
+	// FmtErrPluginRegister formats error for plugin registration failure.
+	FmtErrPluginRegister = "plugin %q failed to register: %s"
+	// FmtErrPluginStartup formats error for plugin startup failure.
+	FmtErrPluginStartup = "plugin %q failed to start: %s"
 	// FmtErrInvalidCommandArgs is returned when invalid args are received by
 	// command parser.
 	FmtErrInvalidCommandArgs = "invalid arguments passed for (%s).Parse"
 	// FmtErrCommandNotProvided when no command is provided calling the application
 	FmtErrCommandNotProvided = "no command, see (%s --help) for available commands"
+	// FmtErrUnknownVersionFormat formats error for unsupported --version format.
+	FmtErrUnknownVersionFormat = "unknown version format %q, supported formats are text and json"
+	// FmtErrPromptNoInput formats error for prompt which requires input when prompting is disabled.
+	FmtErrPromptNoInput = "%q requires input, but prompting is disabled (--no-input or input is not a terminal)"
+	// FmtErrPromptRead formats error for prompt which failed to read the answer.
+	FmtErrPromptRead = "failed to read answer to %q: %s"
+	// FmtErrPromptInvalidChoice formats error for invalid choice of select prompt.
+	FmtErrPromptInvalidChoice = "invalid choice %q, choose number from 1 to %d"
+	// FmtErrUnknownOutputFormat formats error for unsupported --output format.
+	FmtErrUnknownOutputFormat = "unknown output format %q, supported formats are table, json, yaml, csv and template=<template>"
+	// FmtErrInvalidOutputTemplate formats error for output template which fails to parse.
+	FmtErrInvalidOutputTemplate = "invalid output template: %s"
+	// FmtErrInvalidFailurePolicy formats error for invalid failure policy.
+	FmtErrInvalidFailurePolicy = "invalid failure policy %q, must be fail-fast, continue or number of failures"
 )
 
 // Application for CLI Application instance
@@ -76,6 +117,19 @@ type Application struct {
 	osArgs      []string                // raw os args from beginning of the execution
 	currentCmd  *Command
 	rootCmd     Command
+	helpTmpl    string // global help template override
+	cmdHelpTmpl string // command help template override
+	topics      map[string]HelpTopic
+	chainHooks  bool // execute Before and After hooks of parent commands
+	exitFn      func(code int)
+	plugins     []Plugin      // installed plugins
+	running     int           // number of installed plugins which started
+	policy      FailurePolicy // default failure policy of phases
+	promptIn    io.Reader
+	promptOut   io.Writer
+	resultOut   io.Writer // destination of command results
+	output      *Output
+	syslog      *log.Syslog // connection opened by AddSyslog
 }
 
 // New constructs new CLI Application Plugin and returns it's instance for
@@ -89,15 +143,27 @@ func New(prj *project.Project) *Application {
 		flags:       make(map[int]flags.Interface),
 		flagAliases: make(map[string]int),
 		osArgs:      os.Args[1:],
+		exitFn:      os.Exit,
+		policy:      FailFast,
+		promptIn:    os.Stdin,
+		promptOut:   os.Stderr,
+		resultOut:   os.Stdout,
 	}
 	// set initial startup time
 	cli.started = time.Now()
 	cli.Log.TsDisabled()
+	cli.Log.SetLevelOutput(log.ERROR, os.Stderr)
 	if prj.Config.InitTerm {
 		cli.Log.InitTerm()
 	}
 	cli.Log.SetPrimaryColor(prj.Config.Color)
 	cli.Log.SetLogLevel(prj.Config.LogLevel)
+	// text format is the default, invalid format is reported by verifyConfig
+	if format := prj.Config.LogFormat; format != "" && format != log.FormatText {
+		if enc, err := log.NewEncoder(format); err == nil {
+			cli.Log.SetEncoder(enc)
+		}
+	}
 	cli.addInternalFlags()
 	if prj.Config.Color != "" {
 		cli.Log.Colors()
@@ -123,6 +189,8 @@ func New(prj *project.Project) *Application {
 
 	// Add internal commands besides help
 	cli.AddCommand(cmdAbout())
+	cli.AddCommand(cmdHelp(cli))
+	cli.AddCommand(cmdGenerateDocs(cli))
 	cli.rootCmd = NewCommand(prj.Name)
 	cli.Header.Defaults()
 	cli.Footer.Defaults()
@@ -154,6 +222,50 @@ func (cli *Application) AfterFailure(fn func(w *Worker)) {
 	cli.rootCmd.AfterFailure(fn)
 }
 
+// BeforeE root function returning error
+func (cli *Application) BeforeE(fn func(w *Worker) error) {
+	cli.rootCmd.BeforeE(fn)
+}
+
+// DoE root function returning error, see Command.DoE
+func (cli *Application) DoE(fn func(w *Worker) error) {
+	cli.rootCmd.DoE(fn)
+}
+
+// AfterAlwaysE root function returning error
+func (cli *Application) AfterAlwaysE(fn func(w *Worker) error) {
+	cli.rootCmd.AfterAlwaysE(fn)
+}
+
+// AfterSuccessE root function returning error
+func (cli *Application) AfterSuccessE(fn func(w *Worker) error) {
+	cli.rootCmd.AfterSuccessE(fn)
+}
+
+// AfterFailureE root function returning error
+func (cli *Application) AfterFailureE(fn func(w *Worker) error) {
+	cli.rootCmd.AfterFailureE(fn)
+}
+
+// EnableHookChaining enables executing Before and After hooks of root and
+// parent commands when subcommand is called. Before hooks are executed in
+// order root, parent and subcommand and After hooks unwind in reverse order.
+// Failure at any level aborts executing hooks of remaining levels and failure
+// in Before hooks prevents executing Do function.
+func (cli *Application) EnableHookChaining() {
+	cli.chainHooks = true
+}
+
+// hookChain returns commands which Before and After hooks are executed for
+// current command.
+func (cli *Application) hookChain() []*Command {
+	chain := cli.currentCmd.hookChain(cli.chainHooks)
+	if cli.chainHooks && cli.currentCmd.name != cli.Project.Name {
+		chain = append([]*Command{&cli.rootCmd}, chain...)
+	}
+	return chain
+}
+
 // AddCommand to application. Commands and command flags will be verified upon
 // application startup and will prevent application to start if command was
 // invalid or command introduces any flag shadowing.
@@ -203,6 +315,7 @@ func (cli *Application) Start() {
 
 		// Check for application configuration and validity of flags and commands
 		cli.errs.Add(cli.verifyConfig())
+		cli.errs.Add(cli.verifyHelpTopics())
 
 		// parse request flags and arguments
 		cli.errs.Add(cli.prepare())
@@ -216,6 +329,9 @@ func (cli *Application) Start() {
 	// exits with 0 if request was for bash completion
 	cli.handleBashCompletion()
 
+	// Print version information if requested and exit with 0
+	cli.handleVersion()
+
 	// Shall we display default help if so print it and exit with 0
 	cli.handleHelp()
 
@@ -230,39 +346,77 @@ func (cli *Application) Start() {
 		cli.Log.SetLogLevel(log.DEBUG)
 	}
 
+	// keep results parseable when output format is selected
+	// and logs are written to the same file e.g. os.Stdout
+	if f, ok := cli.resultOut.(*os.File); ok && cli.flag("output").Present() &&
+		(cli.Log.Output() == io.Writer(f) || cli.Log.LineOutput() == io.Writer(f)) {
+		cli.Log.SetOutput(os.Stderr)
+	}
 	worker := newWorker(cli.Project, cli.currentCmd.getArgs(), cli.Log)
+	worker.policy = cli.policy
+	worker.prompt = cli.newPrompt()
+	worker.dryRun = cli.flag("dry-run").Present()
+	worker.output = cli.output
 
 	// Add flags
 	cli.processFlags(worker)
+	worker.wrappers = cli.phaseWrappers()
+	if err := cli.startup(worker); err != nil {
+		cli.Header.Print(cli.Log, cli.Project, cli.elapsed())
+		cli.Log.Error(err)
+		cli.shutdown(worker)
+		cli.Footer.Print(cli.Log, cli.Project, cli.elapsed())
+		cli.exit(1)
+	}
 
 	// Start the appMetaData.JSON(lication and reset the start time
 	now := time.Now()
 	cli.Log.Debugf("CLI:Start - startup took %f seconds (excluding before function)",
 		cli.elapsed().Seconds())
 	cli.started = now
+	chain := cli.hookChain()
 	// show header if command has not disabled it
-	cli.currentCmd.executeBeforeFn(worker)
+	executeBeforeFn(worker, chain)
 	if worker.Config.ShowHeader {
 		cli.Header.Print(cli.Log, cli.Project, cli.elapsed())
 	}
-	if worker.Phase().status <= StatusSuccess {
+	if worker.Phase().getStatus() <= StatusSuccess {
 		cli.currentCmd.executeDoFn(worker)
 	}
 	// Do funxtion must exits
-	if worker.Phase().status == StatusSuccess {
-		cli.currentCmd.executeAfterSuccessFn(worker)
-		cli.currentCmd.executeAfterAlwaysFn(worker)
+	if worker.Phase().getStatus() == StatusSuccess {
+		executeAfterSuccessFn(worker, chain)
+		executeAfterAlwaysFn(worker, chain)
+		if worker.DryRun() {
+			simulated := worker.simulatedTasks()
+			msg := fmt.Sprintf("dry-run: no changes were made, %d task(s) simulated", len(simulated))
+			if len(simulated) > 0 {
+				msg += ": " + strings.Join(simulated, ", ")
+			}
+			cli.Log.Notice(msg)
+		}
+		cli.shutdown(worker)
 		// show footer if command has not disabled it
 		if worker.Config.ShowFooter {
 			cli.Footer.Print(cli.Log, cli.Project, cli.elapsed())
 		}
-		cli.exit(0)
+		cli.exit(worker.exitCode(false))
 	}
 	// failure
-	cli.Log.Debugf(FmtErrPhaseFailed, worker.Phase().Name(), worker.Phase().msg)
-	cli.Log.Error(worker.Phase().msg)
-	cli.currentCmd.executeAfterFailureFn(worker)
-	cli.currentCmd.executeAfterAlwaysFn(worker)
+	cli.Log.Debugf(FmtErrPhaseFailed, worker.Phase().Name(), worker.Phase().message())
+	cli.Log.Error(worker.Phase().message())
+	if summary := worker.taskErrorsSummary(); len(summary) > 1 {
+		cli.Log.Errorf("%d task(s) failed", len(summary)-1)
+		for _, row := range summary {
+			cli.Log.Error(row)
+		}
+	}
+	if hint := worker.hint(); hint != "" {
+		cli.Log.Notice(hint)
+	}
+	executeAfterFailureFn(worker, chain)
+	executeAfterAlwaysFn(worker, chain)
+	cli.shutdown(worker)
 	// restore loglevel
 	if llvl != log.DEBUG && cli.flag("debug").Present() && !cli.flag("debug").IsGlobal() {
 		cli.Log.SetLogLevel(llvl)
@@ -271,7 +425,7 @@ func (cli *Application) Start() {
 	if worker.Config.ShowFooter {
 		cli.Footer.Print(cli.Log, cli.Project, cli.elapsed())
 	}
-	cli.exit(1)
+	cli.exit(worker.exitCode(true))
 }
 
 // verifyConfig verifies that configuration is correct
@@ -285,13 +439,19 @@ func (cli *Application) verifyConfig() error {
 	if cli.Project.Name == "" {
 		return errors.New(FmtErrAppUnnamed)
 	}
+	if format := cli.Project.Config.LogFormat; format != "" {
+		if _, err := log.NewEncoder(format); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // Exit application
 // This is called in the end of the execution and takes care of cleaning up runtime before exiting.
 func (cli *Application) exit(code int) {
-	os.Exit(code)
+	cli.closeSyslog()
+	cli.exitFn(code)
 }
 
 // Elapsed returns time.Duration since application was started
@@ -311,48 +471,74 @@ func (cli *Application) flag(name string) flags.Interface {
 
 // prepare runtime
 func (cli *Application) prepare() error {
+	// verify configuration of commands
+	for _, cmd := range cli.commands {
+		if err := cmd.verify(copyFlagAliases(cli.flagAliases)); err != nil {
+			return err
+		}
+	}
+	cmd, err := cli.parse(&cli.osArgs)
+	if err != nil {
+		return err
+	}
+	if onFailure := cli.flag("on-failure"); onFailure.Present() {
+		policy, err := ParseFailurePolicy(onFailure.Value().String())
+		if err != nil {
+			return err
+		}
+		cli.policy = policy
+	}
+	// tables are fitted only into terminal, piped results are not truncated
+	width := 0
+	if f, ok := cli.resultOut.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		width = cli.Log.TermWidth()
+	}
+	output, err := newOutput(cli.resultOut, cli.flag("output").Value().String(), width)
+	if err != nil {
+		return err
+	}
+	cli.output = output
+	cli.currentCmd = cmd
+	if cli.currentCmd != nil {
+		return cli.currentCmd.errs.AsError()
+	}
+	return nil
+}
+
+// parse global flags and requested command from args. Returned command is
+// nil when no command was requested and application has no root command.
+func (cli *Application) parse(args *[]string) (*Command, error) {
 	// global flags
 	for i := 1; i <= len(cli.flags); i++ {
 		// ignore already parsed error since it is valid for predefined global flags
-		if ok, err := cli.flags[i].Parse(&cli.osArgs); err != nil && !ok {
-			return err
+		if ok, err := cli.flags[i].Parse(args); err != nil && !ok {
+			return nil, err
 		}
 	}
 
 	// If we still have global flags left
-	if len(cli.osArgs) > 0 && cli.osArgs[0][0] == '-' {
-		return errors.Newf(FmtErrUnknownGlobalFlag, cli.osArgs[0])
+	if len(*args) > 0 && (*args)[0][0] == '-' {
+		return nil, errors.Newf(FmtErrUnknownGlobalFlag, (*args)[0])
 	}
 
-	// verify configuration of commands
-	for _, cmd := range cli.commands {
-		if err := cmd.verify(cli.flagAliases); err != nil {
-			return err
-		}
-	}
 	// parse requested command
-	if len(cli.osArgs) > 0 {
-		cmd, exists := cli.commands[cli.osArgs[0]]
+	if len(*args) > 0 {
+		cmd, exists := cli.commands[(*args)[0]]
 		if !exists {
-			return errors.Newf(FmtErrUnknownCommand, cli.osArgs[0])
+			return nil, errors.Newf(FmtErrUnknownCommand, (*args)[0])
 		}
-		cli.currentCmd = &cmd
-		if err := cli.currentCmd.parse(&cli.osArgs); err != nil {
-			return err
-		}
-	} else {
-		cmd, exists := cli.commands[cli.Project.Name]
-		if !exists {
-			// Not having root command is not a error.
-			// It is treated as no command was provided
-			return nil
+		if err := cmd.parse(args); err != nil {
+			return nil, err
 		}
-		cli.currentCmd = &cmd
+		return &cmd, nil
 	}
-	if cli.currentCmd != nil {
-		return cli.currentCmd.errs.AsError()
+	cmd, exists := cli.commands[cli.Project.Name]
+	if !exists {
+		// Not having root command is not a error.
+		// It is treated as no command was provided
+		return nil, nil
 	}
-	return nil
+	return &cmd, nil
 }
 
 // checkRuntimeErrors checks if any errors have been added to application
@@ -381,10 +567,49 @@ func (cli *Application) handleBashCompletion() {
 	if cli.flag("show-bash-completion").Present() {
 		// TODO(mkungla): https://github.com/howi-ce/howi/issues/15
 		cli.Log.Error("bash completion not implemented")
-		os.Exit(0)
+		cli.exit(0)
 	}
 }
 
+// handleVersion prints version information as text or JSON depending on
+// value of the --version flag.
+func (cli *Application) handleVersion() {
+	cli.Log.Debugf("CLI:handleVersion - was it version call (%t)",
+		cli.flag("version").Present())
+	if !cli.flag("version").Present() {
+		return
+	}
+	info := cli.Project.BuildInfo()
+	switch format := cli.flag("version").Value().String(); format {
+	case "", "text":
+		fmt.Fprint(cli.resultOut, versionText(info))
+	case "json":
+		b, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			cli.Log.Error(err)
+			cli.exit(1)
+		}
+		fmt.Fprintln(cli.resultOut, string(b))
+	default:
+		cli.Log.Errorf(FmtErrUnknownVersionFormat, format)
+		cli.exit(2)
+	}
+	cli.exit(0)
+}
+
+// SetHelpTemplate overrides template used to display application help.
+// Template receives *HelpGlobal as data.
+func (cli *Application) SetHelpTemplate(tmpl string) {
+	cli.helpTmpl = tmpl
+}
+
+// SetCommandHelpTemplate overrides template used to display help of all
+// commands which have not set their own template with Command.SetHelpTemplate.
+// Template receives *HelpCommand as data.
+func (cli *Application) SetCommandHelpTemplate(tmpl string) {
+	cli.cmdHelpTmpl = tmpl
+}
+
 // handleHelp prints help menu depending on request
 func (cli *Application) handleHelp() {
 	cli.Log.Debugf("CLI:handleHelp - was it help call (%t)",
@@ -393,18 +618,9 @@ func (cli *Application) handleHelp() {
 		elapsed := cli.elapsed()
 		cli.Header.Print(cli.Log, cli.Project, elapsed)
 		if cli.flag("help").IsGlobal() {
-			help := HelpGlobal{
-				Project:  cli.Project,
-				Commands: cli.commands,
-				Flags:    cli.flags,
-			}
-			help.Print(cli.Log)
+			cli.helpGlobal().Print(cli.Log)
 		} else {
-			help := HelpCommand{
-				Project: cli.Project,
-				Command: *cli.currentCmd,
-			}
-			help.Print(cli.Log)
+			cli.helpCommand(*cli.currentCmd).Print(cli.Log)
 		}
 		cli.Footer.Print(cli.Log, cli.Project, elapsed)
 		cli.exit(0)
@@ -465,6 +681,36 @@ func (cli *Application) addInternalFlags() {
 	help.Parse(&cli.osArgs)
 	cli.AddFlag(help)
 
+	version := flags.NewStringFlag("version")
+	version.SetUsage("print version information and exit. [--version=json] prints it as JSON")
+	version.Parse(&cli.osArgs)
+	cli.AddFlag(version)
+
+	yes := flags.NewBoolFlag("yes")
+	yes.SetUsage("answer yes to all confirmations")
+	yes.Parse(&cli.osArgs)
+	cli.AddFlag(yes)
+
+	noInput := flags.NewBoolFlag("no-input")
+	noInput.SetUsage("disable prompts, defaults are used and prompts without default fail")
+	noInput.Parse(&cli.osArgs)
+	cli.AddFlag(noInput)
+
+	output := flags.NewStringFlag("output")
+	output.SetUsage("output format of results: table, json, yaml, csv or template=<go template>")
+	output.Parse(&cli.osArgs)
+	cli.AddFlag(output)
+
+	dryRun := flags.NewBoolFlag("dry-run")
+	dryRun.SetUsage("log side effects of tasks instead of executing them")
+	dryRun.Parse(&cli.osArgs)
+	cli.AddFlag(dryRun)
+
+	onFailure := flags.NewStringFlag("on-failure")
+	onFailure.SetUsage("failure policy of phases: fail-fast, continue or number of failures after which running tasks are canceled")
+	onFailure.Parse(&cli.osArgs)
+	cli.AddFlag(onFailure)
+
 	bashCompletion := flags.NewBoolFlag("show-bash-completion")
 	bashCompletion.Parse(&cli.osArgs)
 	bashCompletion.Hide()
@@ -0,0 +1,401 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digaverse/howi/pkg/errors"
+	"github.com/digaverse/howi/pkg/log"
+	"github.com/digaverse/howi/pkg/project"
+)
+
+// exitCode is used to stop application started in tests on exit.
+type exitCode int
+
+// runApp starts application with given args and returns exit code and
+// output of the application.
+func runApp(t *testing.T, app *Application, args ...string) (code int, out string) {
+	var buf bytes.Buffer
+	app.Log.SetOutput(&buf)
+	app.osArgs = args
+	app.exitFn = func(code int) {
+		panic(exitCode(code))
+	}
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		c, ok := r.(exitCode)
+		if !ok {
+			panic(r)
+		}
+		code, out = int(c), buf.String()
+	}()
+	app.Start()
+	t.Fatal("application did not exit")
+	return
+}
+
+func TestHookChaining(t *testing.T) {
+	tests := []struct {
+		name     string
+		chained  bool
+		failAt   string
+		wantCode int
+		want     []string
+	}{
+		{"not-chained", false, "", 0, []string{
+			"rollback-before", "rollback-do", "rollback-after-success", "rollback-after-always"}},
+		{"chained", true, "", 0, []string{
+			"root-before", "deploy-before", "rollback-before",
+			"rollback-do",
+			"rollback-after-success", "deploy-after-success", "root-after-success",
+			"rollback-after-always", "deploy-after-always", "root-after-always"}},
+		{"abort-before", true, "deploy-before", 1, []string{
+			"root-before", "deploy-before",
+			"rollback-after-failure", "deploy-after-failure", "root-after-failure",
+			"rollback-after-always", "deploy-after-always", "root-after-always"}},
+		{"abort-after", true, "rollback-after-always", 0, []string{
+			"root-before", "deploy-before", "rollback-before",
+			"rollback-do",
+			"rollback-after-success", "deploy-after-success", "root-after-success",
+			"rollback-after-always"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			hook := func(name string) func(w *Worker) {
+				return func(w *Worker) {
+					got = append(got, name)
+					if name == tt.failAt {
+						w.Fail(name + " failed")
+					}
+				}
+			}
+			hooks := func(c *Command, name string) {
+				c.Before(hook(name + "-before"))
+				c.AfterSuccess(hook(name + "-after-success"))
+				c.AfterFailure(hook(name + "-after-failure"))
+				c.AfterAlways(hook(name + "-after-always"))
+			}
+			app := newTestApp(t)
+			if tt.chained {
+				app.EnableHookChaining()
+			}
+			hooks(&app.rootCmd, "root")
+			deploy := app.commands["deploy"]
+			hooks(&deploy, "deploy")
+			rollback := deploy.subCommands["rollback"]
+			hooks(&rollback, "rollback")
+			rollback.Do(hook("rollback-do"))
+			deploy.subCommands["rollback"] = rollback
+			app.commands["deploy"] = deploy
+
+			code, out := runApp(t, app, "deploy", "rollback", "v1")
+			if code != tt.wantCode {
+				t.Errorf("exit code want %d got %d output:\n%s", tt.wantCode, code, out)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hooks executed in wrong order\nwant %q\ngot  %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		do      func(w *Worker)
+		wantMsg string
+	}{
+		{"phase", func(w *Worker) {
+			panic("boom")
+		}, "phase do panic: boom"},
+		{"task", func(w *Worker) {
+			w.Task("explode", func(task *Task) {
+				var m map[string]int
+				m["key"]++
+			})
+		}, "task explode panic: assignment to entry in nil map"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			var phaseErr error
+			app := newTestApp(t)
+			deploy := app.commands["deploy"]
+			deploy.Do(tt.do)
+			deploy.AfterFailure(func(w *Worker) {
+				got = append(got, "after-failure")
+			})
+			deploy.AfterAlways(func(w *Worker) {
+				got = append(got, "after-always")
+				phaseErr = w.phases["do"].Err()
+			})
+			app.commands["deploy"] = deploy
+			app.Log.SetLogLevel(log.DEBUG)
+			code, out := runApp(t, app, "deploy")
+			// internal software error of sysexits.h
+			if code != 70 {
+				t.Errorf("exit code want 70 got %d", code)
+			}
+			if want := []string{"after-failure", "after-always"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("cleanup phases want %q got %q", want, got)
+			}
+			st, ok := phaseErr.(*errors.ErrorWithStackTrace)
+			if !ok {
+				t.Fatalf("phase error want *errors.ErrorWithStackTrace got %T", phaseErr)
+			}
+			if st.Error() != tt.wantMsg {
+				t.Errorf("phase error want %q got %q", tt.wantMsg, st.Error())
+			}
+			if len(st.GetStackTrace()) == 0 {
+				t.Error("phase error should contain stack trace")
+			}
+			if !strings.Contains(out, "lib/cli/cli_test.go") {
+				t.Errorf("debug output should contain stack trace got:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestExitError(t *testing.T) {
+	errNotFound := errors.New("release not found")
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantHint string
+	}{
+		{"error", errNotFound, ExitFailure, ""},
+		{"exit-error", NewExitError(3, errNotFound).WithHint("see available releases with: app releases"), 3,
+			"see available releases with: app releases"},
+		{"sysexits", NewExitError(ExitNoPerm, errNotFound), ExitNoPerm, ""},
+		{"wrapped", fmt.Errorf("deploy: %w", NewExitError(ExitConfig, errNotFound)), ExitConfig, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var afterErr error
+			app := newTestApp(t)
+			deploy := app.commands["deploy"]
+			deploy.DoE(func(w *Worker) error {
+				return tt.err
+			})
+			deploy.AfterFailure(func(w *Worker) {
+				afterErr = w.Err()
+			})
+			app.commands["deploy"] = deploy
+			code, out := runApp(t, app, "deploy")
+			if code != tt.wantCode {
+				t.Errorf("exit code want %d got %d", tt.wantCode, code)
+			}
+			if !errors.Is(afterErr, errNotFound) {
+				t.Errorf("AfterFailure should receive original error got %v", afterErr)
+			}
+			var exitErr *ExitError
+			if isExitErr := errors.As(afterErr, &exitErr); isExitErr != (tt.wantCode != ExitFailure) {
+				t.Errorf("errors.As(*ExitError) = %t", isExitErr)
+			}
+			if tt.wantHint != "" && !strings.Contains(out, tt.wantHint) {
+				t.Errorf("output should contain hint %q got:\n%s", tt.wantHint, out)
+			}
+		})
+	}
+}
+
+func TestBeforeE(t *testing.T) {
+	var executed bool
+	app := newTestApp(t)
+	deploy := app.commands["deploy"]
+	deploy.BeforeE(func(w *Worker) error {
+		return NewExitError(ExitUnavailable, errors.New("service unavailable"))
+	})
+	deploy.Do(func(w *Worker) {
+		executed = true
+	})
+	app.commands["deploy"] = deploy
+	if code, _ := runApp(t, app, "deploy"); code != ExitUnavailable {
+		t.Errorf("exit code want %d got %d", ExitUnavailable, code)
+	}
+	if executed {
+		t.Error("Do should not be executed when Before fails")
+	}
+}
+
+func TestApplicationPhaseE(t *testing.T) {
+	errUnavailable := NewExitError(ExitUnavailable, errors.New("service unavailable"))
+	tests := []struct {
+		name      string
+		beforeErr error
+		doErr     error
+		wantCode  int
+		want      []string
+	}{
+		{"success", nil, nil, ExitOK, []string{"before", "do", "after-success", "after-always"}},
+		{"before", errUnavailable, nil, ExitUnavailable, []string{"before", "after-failure", "after-always"}},
+		{"do", nil, errUnavailable, ExitUnavailable, []string{"before", "do", "after-failure", "after-always"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			app := newTestApp(t)
+			app.BeforeE(func(w *Worker) error {
+				got = append(got, "before")
+				return tt.beforeErr
+			})
+			app.DoE(func(w *Worker) error {
+				got = append(got, "do")
+				return tt.doErr
+			})
+			app.AfterSuccessE(func(w *Worker) error {
+				got = append(got, "after-success")
+				return nil
+			})
+			app.AfterFailureE(func(w *Worker) error {
+				got = append(got, "after-failure")
+				return nil
+			})
+			app.AfterAlwaysE(func(w *Worker) error {
+				got = append(got, "after-always")
+				return nil
+			})
+			code, out := runApp(t, app)
+			if code != tt.wantCode {
+				t.Errorf("exit code want %d got %d output:\n%s", tt.wantCode, code, out)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("phases want %q got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTaskErrors(t *testing.T) {
+	errUpload := errors.New("upload failed")
+	var terrs []*TaskError
+	var phaseErrs errors.MultiError
+	app := newTestApp(t)
+	deploy := app.commands["deploy"]
+	deploy.Do(func(w *Worker) {
+		w.Task("upload", func(task *Task) {
+			task.FailErr(errUpload)
+		})
+		w.Task("migrate", func(task *Task) {
+			task.Fail("migration failed")
+		})
+		w.Task("notify", func(task *Task) {})
+	})
+	deploy.AfterFailure(func(w *Worker) {
+		terrs = w.TaskErrors()
+		phaseErrs = w.phases["do"].Errors()
+	})
+	app.commands["deploy"] = deploy
+	code, out := runApp(t, app, "deploy")
+	if code != ExitFailure {
+		t.Errorf("exit code want %d got %d", ExitFailure, code)
+	}
+	if len(terrs) != 2 || phaseErrs.Len() != 2 {
+		t.Fatalf("want 2 task errors got %d (phase errors %d)", len(terrs), phaseErrs.Len())
+	}
+	got := map[string]*TaskError{}
+	for _, terr := range terrs {
+		got[terr.Task] = terr
+	}
+	if got["upload"] == nil || !errors.Is(got["upload"], errUpload) {
+		t.Errorf("upload task error should wrap original error got %v", got["upload"])
+	}
+	if got["migrate"] == nil || got["migrate"].Phase != "do" ||
+		got["migrate"].Error() != "task migrate: migration failed" {
+		t.Errorf("unexpected migrate task error %v", got["migrate"])
+	}
+	for _, want := range []string{"2 task(s) failed", "PHASE", "do     upload   upload failed", "do     migrate  migration failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q got:\n%s", want, out)
+		}
+	}
+}
+
+func TestLogFormatConfig(t *testing.T) {
+	newApp := func(format string) *Application {
+		prj, err := project.New([]byte(`{"name": "app", "namespace": "howi", "config": {"loglevel": 7, "logformat": "` + format + `"}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		app := New(prj)
+		deploy := NewCommand("deploy")
+		deploy.Do(func(w *Worker) {})
+		app.AddCommand(deploy)
+		return app
+	}
+	app := newApp("logfmt")
+	if _, ok := app.Log.Encoder().(log.LogfmtEncoder); !ok {
+		t.Errorf("want logfmt encoder got %T", app.Log.Encoder())
+	}
+	code, out := runApp(t, newApp("xml"), "deploy")
+	if code != 2 || !strings.Contains(out, `unknown log format "xml"`) {
+		t.Errorf("invalid log format should fail with code 2 got %d output:\n%s", code, out)
+	}
+}
+
+func TestVersionFlag(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		date       string
+		commitDate string
+		want       string
+	}{
+		{"text", []string{"--version"}, "2018-03-06T03:06:34+02:00", "",
+			"app 0.0.0\ncommit:                        1a2b3c (dirty)\n" +
+				"build date:                    2018-03-06 03:06:34 +0200 +0200\ngo version:                    go1.21.0\n"},
+		{"json", []string{"--version=json"}, "2018-03-06T03:06:34+02:00", "",
+			"{\n  \"name\": \"app\",\n  \"version\": \"0.0.0\",\n  \"commit\": \"1a2b3c\",\n  \"dirty\": true,\n" +
+				"  \"builddate\": \"2018-03-06T03:06:34+02:00\",\n  \"goversion\": \"go1.21.0\"\n}\n"},
+		{"about command", []string{"about-howi", "--version"}, "2018-03-06T03:06:34+02:00", "",
+			"app 0.0.0\ncommit:                        1a2b3c (dirty)\n" +
+				"build date:                    2018-03-06 03:06:34 +0200 +0200\ngo version:                    go1.21.0\n"},
+		{"json without build date", []string{"--version=json"}, "", "",
+			"{\n  \"name\": \"app\",\n  \"version\": \"0.0.0\",\n  \"commit\": \"1a2b3c\",\n  \"dirty\": true,\n" +
+				"  \"goversion\": \"go1.21.0\"\n}\n"},
+		{"commit date", []string{"--version=json"}, "", "2018-03-01T10:00:00Z",
+			"{\n  \"name\": \"app\",\n  \"version\": \"0.0.0\",\n  \"commit\": \"1a2b3c\",\n  \"dirty\": true,\n" +
+				"  \"commitdate\": \"2018-03-01T10:00:00Z\",\n  \"goversion\": \"go1.21.0\"\n}\n"},
+		{"commit date text", []string{"--version"}, "", "2018-03-01T10:00:00Z",
+			"app 0.0.0\ncommit:                        1a2b3c (dirty)\n" +
+				"commit date:                   2018-03-01 10:00:00 +0000 UTC\ngo version:                    go1.21.0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result bytes.Buffer
+			app := newTestApp(t)
+			app.SetResultOutput(&result)
+			app.Project.Commit = "1a2b3c"
+			app.Project.Dirty = true
+			app.Project.BuildDate = time.Time{}
+			if tt.date != "" {
+				app.Project.BuildDate, _ = time.Parse(time.RFC3339, tt.date)
+			}
+			app.Project.CommitDate = time.Time{}
+			if tt.commitDate != "" {
+				app.Project.CommitDate, _ = time.Parse(time.RFC3339, tt.commitDate)
+			}
+			app.Project.GoVersion = "go1.21.0"
+			code, out := runApp(t, app, tt.args...)
+			if code != 0 {
+				t.Fatalf("exit code want 0 got %d output:\n%s", code, out)
+			}
+			if result.String() != tt.want {
+				t.Errorf("want:\n%q\ngot:\n%q", tt.want, result.String())
+			}
+		})
+	}
+}
@@ -6,8 +6,11 @@ package cli
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/digaverse/howi/lib/cli/flags"
+	"github.com/digaverse/howi/pkg/project"
 )
 
 func cmdAbout() Command {
@@ -19,20 +22,18 @@ func cmdAbout() Command {
 	contributors.SetUsage("print project contributors list")
 	cmd.AddFlag(contributors)
 
-	version := flags.NewBoolFlag("version")
-	version.SetUsage("print version string")
-	cmd.AddFlag(version)
-
 	buildDate := flags.NewBoolFlag("build-date")
 	buildDate.SetUsage("print build date")
 	cmd.AddFlag(buildDate)
 
+	cmd.AddExample("about-howi --contributors", "List project contributors")
+	cmd.AddExample("about-howi --output=json", "Print project information as JSON")
+	cmd.AddExample("about-howi --version", "Print build information of the binary")
+
 	cmd.Before(func(w *Worker) {
-		version, _ := w.Flag("version")
-		showVersion, _ := version.Value().Bool()
 		buildDate, _ := w.Flag("build-date")
 		showBuildDate, _ := buildDate.Value().Bool()
-		if showVersion || showBuildDate {
+		if showBuildDate || w.Output().Format() != OutputTable {
 			w.Config.ShowHeader = false
 			w.Config.ShowFooter = false
 		}
@@ -44,42 +45,125 @@ func cmdAbout() Command {
 }
 
 func aboutCLIdo(w *Worker) {
+	about := w.Project.About()
 	contributors, _ := w.Flag("contributors")
 	if show, _ := contributors.Value().Bool(); show {
-		w.Log.Line("Project Contributors\n")
-		for _, contributor := range w.Project.Contributors {
-			w.Log.Line(contributor.String())
-		}
+		writeAboutText(w, contributorsText(about.Contributors))
 		return
 	}
 	buildDate, _ := w.Flag("build-date")
 	if show, _ := buildDate.Value().Bool(); show {
-		fmt.Print(w.Project.BuildDate)
+		writeAboutText(w, fmt.Sprintln(w.Project.BuildDate))
 		return
 	}
-	version, _ := w.Flag("version")
-	if show, _ := version.Value().Bool(); show {
-		fmt.Print(w.Project.Version)
+	if w.Output().Format() == OutputTable {
+		writeAboutText(w, aboutText(about))
 		return
 	}
+	if err := w.Output().Write(about); err != nil {
+		w.Fail(err.Error())
+	}
+}
 
-	w.Log.Line("ABOUT")
-	w.Log.Line("------------------------------------------------------------------------")
-	w.Log.Line(w.Project.Description)
-	w.Log.Line("------------------------------------------------------------------------")
-	w.Log.Line(tableRow("Version:", w.Project.Version))
-	w.Log.Line(tableRow("Build date:", w.Project.BuildDate))
-	w.Log.Line(tableRow("Total contributors:", len(w.Project.Contributors)))
-	w.Log.Line("------------------------------------------------------------------------")
-	w.Log.Line("Project Contributors\n")
-	for _, contributor := range w.Project.Contributors {
-		w.Log.Line(contributor.String())
+// writeAboutText writes text to results so that it is not mixed with logs.
+func writeAboutText(w *Worker, text string) {
+	if err := w.Output().writeText(text); err != nil {
+		w.Fail(err.Error())
 	}
-	w.Log.Line("------------------------------------------------------------------------")
-	w.Log.Line("for flags printing additional info use --help")
+}
 
+const aboutSeparator = "------------------------------------------------------------------------\n"
+
+// aboutText formats project information as printed in table format.
+func aboutText(about project.About) string {
+	var b strings.Builder
+	b.WriteString("ABOUT\n")
+	b.WriteString(aboutSeparator)
+	b.WriteString(about.Description + "\n")
+	b.WriteString(aboutSeparator)
+	b.WriteString(tableRow("Version:", about.Version) + "\n")
+	if about.BuildDate != nil {
+		b.WriteString(tableRow("Build date:", about.BuildDate) + "\n")
+	}
+	if about.Commit != "" {
+		b.WriteString(tableRow("Commit:", commitText(about.Commit, about.Dirty)) + "\n")
+	}
+	if about.CommitDate != nil {
+		b.WriteString(tableRow("Commit date:", about.CommitDate) + "\n")
+	}
+	b.WriteString(tableRow("Go version:", about.GoVersion) + "\n")
+	optionalRows := [][2]string{
+		{"License:", about.License},
+		{"Homepage:", about.Homepage},
+		{"Repository:", about.Repository},
+		{"Author:", about.Author},
+		{"Keywords:", strings.Join(about.Keywords, ", ")},
+	}
+	if about.Bugs != nil {
+		optionalRows = append(optionalRows,
+			[2]string{"Bugs:", about.Bugs.URL},
+			[2]string{"Bugs email:", about.Bugs.Email.String()})
+	}
+	for _, row := range optionalRows {
+		if row[1] != "" {
+			b.WriteString(tableRow(row[0], row[1]) + "\n")
+		}
+	}
+	b.WriteString(tableRow("Total contributors:", len(about.Contributors)) + "\n")
+	b.WriteString(aboutSeparator)
+	b.WriteString(contributorsText(about.Contributors))
+	if len(about.Dependencies) > 0 {
+		b.WriteString(aboutSeparator)
+		b.WriteString("Dependencies\n\n")
+		var names []string
+		for name := range about.Dependencies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b.WriteString(tableRow(name, about.Dependencies[name]) + "\n")
+		}
+	}
+	b.WriteString(aboutSeparator)
+	b.WriteString("for flags printing additional info use --help\n")
+	return b.String()
+}
+
+func contributorsText(contributors []string) string {
+	var b strings.Builder
+	b.WriteString("Project Contributors\n\n")
+	for _, contributor := range contributors {
+		b.WriteString(contributor + "\n")
+	}
+	return b.String()
 }
 
 func tableRow(key string, val interface{}) string {
 	return fmt.Sprintf("%-30s %v", key, val)
 }
+
+// versionText formats build info as printed by --version flag.
+func versionText(info project.BuildInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", info.Name, info.Version)
+	if info.Commit != "" {
+		b.WriteString(tableRow("commit:", commitText(info.Commit, info.Dirty)) + "\n")
+	}
+	if info.CommitDate != nil {
+		b.WriteString(tableRow("commit date:", info.CommitDate) + "\n")
+	}
+	if info.BuildDate != nil {
+		b.WriteString(tableRow("build date:", info.BuildDate) + "\n")
+	}
+	if info.GoVersion != "" {
+		b.WriteString(tableRow("go version:", info.GoVersion) + "\n")
+	}
+	return b.String()
+}
+
+func commitText(commit string, dirty bool) string {
+	if dirty {
+		return commit + " (dirty)"
+	}
+	return commit
+}
@@ -5,58 +5,187 @@
 package cli
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/digaverse/howi/lib/cli/flags"
+	"github.com/digaverse/howi/pkg/errors"
 	"github.com/digaverse/howi/pkg/log"
 	"github.com/digaverse/howi/pkg/project"
 )
 
+const (
+	// helpRowIndent is indentation of command and flag rows in help output.
+	helpRowIndent = 2
+	// helpMaxColumn limits width of the command and flag name columns,
+	// longer names are followed by description on the next line.
+	helpMaxColumn = 30
+	// helpMaxArgs limits depth of command path accepted by help command.
+	helpMaxArgs = 16
+)
+
 var (
-	helpGlobalTmpl = `{{if .Info.ShortDescription}}{{ .Info.ShortDescription }}{{end}}
+	helpGlobalTmpl = `{{ if .Project.Title }}{{ funcWrap 0 .Project.Title }}{{ end }}
 
  Usage:
-  {{ .Info.Name }} command
-  {{ .Info.Name }} command [command-flags] [arguments]
-  {{ .Info.Name }} [global-flags] command [command-flags] [arguments]
-  {{ .Info.Name }} [global-flags] command ...subcommand [command-flags] [arguments]
-
- The commands are:{{ if .PrimaryCommands }}{{ range $cmdObj := .PrimaryCommands }}
-  {{ $cmdObj.Name | funcCmdName }}{{ $cmdObj.ShortDesc }}{{ end }}{{ end }}
-{{ if .CommandsCategorized }}{{ range $cat, $cmds := .CommandsCategorized }}
- {{ $cat | funcCmdCategory }}
- {{ range $cmdObj := $cmds }}
- {{$cmdObj.Name | funcCmdName }}{{ $cmdObj.ShortDesc }}{{ end }}
- {{ end }}{{ end }}
-
- The global flags are:{{ if .Flags }}{{ range $flag := .Flags }}{{ if not .IsHidden }}
-  {{$flag.HelpName | funcFlagName }}{{ $flag.Usage }}{{ if $flag.HelpAliases }}
-   {{$flag.HelpAliases}}
-{{ end }}{{ end }}{{ end }}{{ end }}
-{{if .Info.LongDescription}}{{ .Info.LongDescription }}{{end}}`
-
-	helpCommandTmpl = `{{ if .Command.LongDesc }}{{.Command.LongDesc}}{{ else }}{{ .Command.ShortDesc }}{{ end }}
+  {{ .Project.Name }} command
+  {{ .Project.Name }} command [command-flags] [arguments]
+  {{ .Project.Name }} [global-flags] command [command-flags] [arguments]
+  {{ .Project.Name }} [global-flags] command ...subcommand [command-flags] [arguments]
 
- Usage:
-   {{ .Usage | funcTextBold }}{{ if .Command.Usage }}
-   {{ .Command.Usage }}{{ end }}
-{{ if .Command.HasSubcommands }}
- {{ print "Subcommands" | funcCmdCategory }}
-{{ range $cmdObj := .Command.GetSubcommands }}
-{{ $cmdObj.Name | funcCmdName }}{{ $cmdObj.ShortDesc }}{{ end }}
+ The commands are:{{ range $cmd := .PrimaryCommands }}
+  {{ funcCmdName $cmd.Name }}{{ funcCmdDesc $cmd.ShortDesc }}{{ end }}
+{{ range $cat, $cmds := .CommandsCategorized }}
+ {{ funcCmdCategory $cat }}{{ range $cmd := $cmds }}
+  {{ funcCmdName $cmd.Name }}{{ funcCmdDesc $cmd.ShortDesc }}{{ end }}
 {{ end }}
-{{ if .Command.AcceptsFlags }} Accepts following flags:{{ range $flag := .Flags }}{{ if not .IsHidden }}
- {{$flag.HelpName | funcFlagName }}{{ $flag.Usage }}{{ if $flag.HelpAliases }}
-	{{$flag.HelpAliases}}
-{{ end }}{{ end }}{{ end }}{{ end }}`
+ The global flags are:{{ range $flag := .Flags }}
+  {{ funcFlagName (funcFlagLabel $flag) }}{{ funcFlagDesc $flag.Usage }}{{ end }}
+{{ if .Topics }}
+ Additional help topics:{{ range $topic := .Topics }}
+  {{ funcCmdName $topic.Name }}{{ funcCmdDesc $topic.Title }}{{ end }}
+
+ Use "{{ .Project.Name }} help <topic>" for more information about that topic.
+{{ end }}{{ if .Project.Description }}
+{{ funcWrap 0 .Project.Description }}
+{{ end }}`
+
+	helpCommandTmpl = `{{ if .Command.LongDesc }}{{ funcWrap 0 .Command.LongDesc }}{{ else }}{{ funcWrap 0 .Command.ShortDesc }}{{ end }}
+
+ Usage:
+  {{ funcTextBold .Usage }}{{ if .Command.Usage }}
+  {{ funcWrap 2 .Command.Usage }}{{ end }}
+{{ if .Subcommands }}
+ {{ funcCmdCategory "subcommands" }}{{ range $cmd := .Subcommands }}
+  {{ funcCmdName $cmd.Name }}{{ funcCmdDesc $cmd.ShortDesc }}{{ end }}
+{{ end }}{{ if .Flags }}
+ Accepts following flags:{{ range $flag := .Flags }}
+  {{ funcFlagName (funcFlagLabel $flag) }}{{ funcFlagDesc $flag.Usage }}{{ end }}
+{{ end }}{{ if .InheritedFlags }}
+ Inherited flags:{{ range $flag := .InheritedFlags }}
+  {{ funcFlagName (funcFlagLabel $flag) }}{{ funcFlagDesc $flag.Usage }}{{ end }}
+{{ end }}{{ if .Examples }}
+ {{ funcCmdCategory "examples" }}{{ range $ex := .Examples }}{{ if $ex.Desc }}
+  {{ funcWrap 2 $ex.Desc }}{{ end }}
+    $ {{ $.Project.Name }} {{ $ex.Cmd }}{{ end }}
+{{ end }}{{ if .SeeAlso }}
+ {{ funcCmdCategory "see also" }}{{ range $ref := .SeeAlso }}
+  {{ $.Project.Name }} {{ $ref }}{{ end }}
+{{ end }}`
+
+	helpTreeTmpl = `{{ if .Project.Title }}{{ funcWrap 0 .Project.Title }}{{ end }}
+
+ {{ funcCmdCategory "commands" }}{{ range $entry := .Entries }}
+  {{ funcIndent $entry.Indent }}{{ funcTextBold $entry.Name }}  {{ funcCmdDesc $entry.Command.ShortDesc }}{{ end }}
+
+ Use "{{ .Project.Name }} help <command> [subcommand...]" for more information about a command.
+`
+
+	helpTopicsTmpl = `{{ funcCmdCategory "help topics" }}{{ range $topic := .Topics }}
+  {{ funcCmdName $topic.Name }}{{ funcCmdDesc $topic.Title }}{{ else }}
+  no help topics{{ end }}
+`
+
+	helpTopicTmpl = `{{ with .Topic }}{{ funcTextBold .Title }}
+
+{{ .Body }}{{ end }}
+`
 )
 
+// HelpTopic is long form help page registered by application which is
+// displayed with "app help <topic>".
+type HelpTopic struct {
+	Name  string
+	Title string
+	Body  string
+}
+
+// AddHelpTopic registers help topic displayed with "app help <name>".
+// Topic name must not be used by any command or be "topics", which is
+// verified when application starts.
+func (cli *Application) AddHelpTopic(name, title, body string) {
+	if cli.topics == nil {
+		cli.topics = make(map[string]HelpTopic)
+	}
+	if _, exists := cli.topics[name]; exists {
+		cli.errs.Add(errors.Newf(FmtErrHelpTopicInUse, name))
+		return
+	}
+	cli.topics[name] = HelpTopic{
+		Name:  name,
+		Title: title,
+		Body:  strings.TrimSpace(body),
+	}
+}
+
+// verifyHelpTopics checks that help topics are not named as commands or
+// "topics" which lists help topics.
+func (cli *Application) verifyHelpTopics() error {
+	for _, topic := range cli.Topics() {
+		if _, exists := cli.commands[topic.Name]; exists || topic.Name == "topics" {
+			return errors.Newf(FmtErrHelpTopicInUse, topic.Name)
+		}
+	}
+	return nil
+}
+
+// Topics returns registered help topics sorted by name.
+func (cli *Application) Topics() []HelpTopic {
+	var names []string
+	for name := range cli.topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var topics []HelpTopic
+	for _, name := range names {
+		topics = append(topics, cli.topics[name])
+	}
+	return topics
+}
+
+func (cli *Application) helpGlobal() *HelpGlobal {
+	return &HelpGlobal{
+		Template: cli.helpTmpl,
+		Project:  cli.Project,
+		Commands: cli.commands,
+		Flags:    visibleFlags(cli.flags),
+		Topics:   cli.Topics(),
+	}
+}
+
+func (cli *Application) helpCommand(cmd Command) *HelpCommand {
+	return &HelpCommand{
+		Template:       cli.cmdHelpTmpl,
+		Project:        cli.Project,
+		Command:        cmd,
+		InheritedFlags: cli.inheritedFlags(cmd.getParents()),
+	}
+}
+
+// inheritedFlags returns persistent flags of commands on given path.
+func (cli *Application) inheritedFlags(path []string) []flags.Interface {
+	var inherited []flags.Interface
+	cmds := cli.commands
+	for _, name := range path {
+		cmd, exists := cmds[name]
+		if !exists {
+			break
+		}
+		inherited = append(inherited, cmd.persistentFlags()...)
+		cmds = cmd.subCommands
+	}
+	return inherited
+}
+
 // HelpGlobal used to show help for application
 type HelpGlobal struct {
 	TmplParser
+	Template            string // template to use instead of default
 	Project             *project.Project
 	Commands            map[string]Command
-	Flags               map[int]flags.Interface
+	Flags               []flags.Interface
+	Topics              []HelpTopic
 	PrimaryCommands     []Command
 	CommandsCategorized map[string][]Command
 }
@@ -64,7 +193,15 @@ type HelpGlobal struct {
 // Print application help
 func (h *HelpGlobal) Print(log *log.Logger) {
 	h.SetTemplate(helpGlobalTmpl)
-	for _, cmdObj := range h.Commands {
+	if h.Template != "" {
+		h.SetTemplate(h.Template)
+	}
+	var names []string
+	for _, cmdObj := range sortedCommands(h.Commands) {
+		if cmdObj.hidden {
+			continue
+		}
+		names = append(names, cmdObj.name)
 		if cmdObj.category == "" {
 			h.PrimaryCommands = append(h.PrimaryCommands, cmdObj)
 		} else {
@@ -75,6 +212,12 @@ func (h *HelpGlobal) Print(log *log.Logger) {
 				cmdObj)
 		}
 	}
+	for _, topic := range h.Topics {
+		names = append(names, topic.Name)
+	}
+	h.Flags = helpFlags(h.Flags)
+	h.SetOutput(log.ColorsEnabled(), log.TermWidth())
+	h.SetColumns(helpColumn(names), helpColumn(flagLabels(h.Flags)))
 	err := h.ParseTmpl("help-global-tmpl", h, 0)
 	if err != nil {
 		log.Fatal(err)
@@ -85,37 +228,215 @@ func (h *HelpGlobal) Print(log *log.Logger) {
 // HelpCommand is used to display help for command
 type HelpCommand struct {
 	TmplParser
-	Project *project.Project
-	Command Command
-	Usage   string
-	Flags   []flags.Interface
+	Template       string // template to use instead of default
+	Project        *project.Project
+	Command        Command
+	Usage          string
+	Flags          []flags.Interface
+	InheritedFlags []flags.Interface // persistent flags of parent commands
+	Subcommands    []Command
+	Examples       []Example
+	SeeAlso        []string
 }
 
 // Print command help
 func (h *HelpCommand) Print(log *log.Logger) {
 	h.SetTemplate(helpCommandTmpl)
-	usage := []string{h.Project.Name}
-	for _, parent := range h.Command.getParents() {
-		usage = append(usage, parent)
+	if h.Template != "" {
+		h.SetTemplate(h.Template)
+	}
+	if tmpl := h.Command.HelpTemplate(); tmpl != "" {
+		h.SetTemplate(tmpl)
+	}
+	path := append([]string{h.Project.Name}, h.Command.getParents()...)
+	h.Usage = commandUsage(append(path, h.Command.Name()), h.Command)
+
+	h.Flags = helpFlags(h.Command.localFlags())
+	h.InheritedFlags = helpFlags(h.InheritedFlags)
+	var names []string
+	for _, cmdObj := range h.Command.GetSubcommands() {
+		if cmdObj.hidden {
+			continue
+		}
+		names = append(names, cmdObj.name)
+		h.Subcommands = append(h.Subcommands, cmdObj)
+	}
+	h.Examples = h.Command.Examples()
+	h.SeeAlso = h.Command.SeeAlso()
+	h.SetOutput(log.ColorsEnabled(), log.TermWidth())
+	h.SetColumns(helpColumn(names), helpColumn(append(flagLabels(h.Flags), flagLabels(h.InheritedFlags)...)))
+	err := h.ParseTmpl("help-command-tmpl", h, 0)
+	if err != nil {
+		log.Fatal(err)
 	}
-	usage = append(usage, h.Command.Name())
-	if h.Command.AcceptsFlags() {
-		usage = append(usage, "[flags]")
+	log.Line(h.String())
+}
+
+// HelpTree is used to display all commands and subcommands of application.
+type HelpTree struct {
+	TmplParser
+	Project  *project.Project
+	Commands map[string]Command
+	Entries  []HelpTreeEntry
+}
+
+// HelpTreeEntry is command within HelpTree.
+type HelpTreeEntry struct {
+	Name    string // name padded to align descriptions
+	Indent  int    // indentation based on command depth
+	Command Command
+}
+
+// Print command tree
+func (h *HelpTree) Print(log *log.Logger) {
+	h.SetTemplate(helpTreeTmpl)
+	var names []string
+	var walk func(cmds map[string]Command, depth int)
+	walk = func(cmds map[string]Command, depth int) {
+		for _, cmd := range sortedCommands(cmds) {
+			if cmd.hidden {
+				continue
+			}
+			h.Entries = append(h.Entries, HelpTreeEntry{Name: cmd.name, Indent: depth * 2, Command: cmd})
+			// columns include indentation so that descriptions are aligned
+			names = append(names, strings.Repeat(" ", depth*2)+cmd.name)
+			walk(cmd.subCommands, depth+1)
+		}
 	}
-	if h.Command.HasSubcommands() {
-		usage = append(usage, "[subcommands]")
+	walk(h.Commands, 0)
+	col := helpColumn(names)
+	for i := range h.Entries {
+		h.Entries[i].Name = fmt.Sprintf("%-*s", col-h.Entries[i].Indent, h.Entries[i].Name)
 	}
-	if h.Command.AcceptsArgs() {
-		usage = append(usage, "[args]")
+	h.SetOutput(log.ColorsEnabled(), log.TermWidth())
+	h.SetColumns(col, 0)
+	err := h.ParseTmpl("help-tree-tmpl", h, 0)
+	if err != nil {
+		log.Fatal(err)
 	}
-	h.Usage = strings.Join(usage, " ")
+	log.Line(h.String())
+}
+
+// HelpTopics is used to list help topics or display one of them.
+type HelpTopics struct {
+	TmplParser
+	Project *project.Project
+	Topics  []HelpTopic
+	Topic   *HelpTopic // topic to display, if nil list of topics is displayed
+}
 
-	for _, flag := range h.Command.getFlags() {
-		h.Flags = append(h.Flags, flag)
+// Print help topics
+func (h *HelpTopics) Print(log *log.Logger) {
+	h.SetTemplate(helpTopicsTmpl)
+	var names []string
+	for _, topic := range h.Topics {
+		names = append(names, topic.Name)
 	}
-	err := h.ParseTmpl("help-global-tmpl", h, 0)
+	if h.Topic != nil {
+		h.SetTemplate(helpTopicTmpl)
+	}
+	h.SetOutput(log.ColorsEnabled(), log.TermWidth())
+	h.SetColumns(helpColumn(names), 0)
+	err := h.ParseTmpl("help-topics-tmpl", h, 0)
 	if err != nil {
 		log.Fatal(err)
 	}
 	log.Line(h.String())
 }
+
+// cmdHelp is "help" command which displays help for commands, subcommands
+// and help topics sharing rendering with --help flag.
+func cmdHelp(cli *Application) Command {
+	cmd := NewCommand("help")
+	cmd.SetShortDesc("Display help for command or help topic")
+	cmd.SetCategory("internal")
+	cmd.SetUsage("help [command [subcommand...]] | help topics | help <topic>")
+	// command path can be arbitrary deep
+	cmd.ArgsAllowed(helpMaxArgs)
+
+	all := flags.NewBoolFlag("all")
+	all.SetUsage("display all commands and subcommands")
+	cmd.AddFlag(all)
+
+	cmd.AddExample("help about-howi", "Display help for about-howi command")
+	cmd.AddExample("help --all", "Display all commands and subcommands")
+	cmd.AddExample("help topics", "List help topics")
+
+	cmd.Do(func(w *Worker) {
+		if all, _ := w.Flag("all"); all.Present() {
+			help := HelpTree{Project: cli.Project, Commands: cli.commands}
+			help.Print(w.Log)
+			return
+		}
+		var path []string
+		for _, arg := range w.Args() {
+			path = append(path, arg.String())
+		}
+		if len(path) == 0 {
+			cli.helpGlobal().Print(w.Log)
+			return
+		}
+		if c := cli.lookupCommand(strings.Join(path, " ")); c != nil && !c.hidden {
+			cli.helpCommand(*c).Print(w.Log)
+			return
+		}
+		if len(path) == 1 {
+			if path[0] == "topics" {
+				help := HelpTopics{Project: cli.Project, Topics: cli.Topics()}
+				help.Print(w.Log)
+				return
+			}
+			if topic, exists := cli.topics[path[0]]; exists {
+				help := HelpTopics{Project: cli.Project, Topics: cli.Topics(), Topic: &topic}
+				help.Print(w.Log)
+				return
+			}
+		}
+		w.Failf(FmtErrUnknownHelpTopic, strings.Join(path, " "), cli.Project.Name)
+	})
+	return cmd
+}
+
+// helpFlags returns visible flags sorted by name.
+func helpFlags(list []flags.Interface) []flags.Interface {
+	var visible []flags.Interface
+	for _, flag := range list {
+		if !flag.IsHidden() {
+			visible = append(visible, flag)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool {
+		return visible[i].Name() < visible[j].Name()
+	})
+	return visible
+}
+
+// flagLabel returns flag name with its aliases e.g. "--target, -t".
+func flagLabel(flag flags.Interface) string {
+	if aliases := flag.HelpAliases(); aliases != "" {
+		return flag.HelpName() + ", " + strings.Replace(aliases, ",", ", ", -1)
+	}
+	return flag.HelpName()
+}
+
+func flagLabels(list []flags.Interface) []string {
+	var labels []string
+	for _, flag := range list {
+		labels = append(labels, flagLabel(flag))
+	}
+	return labels
+}
+
+// helpColumn returns column width fitting all names up to helpMaxColumn.
+func helpColumn(names []string) int {
+	col := 0
+	for _, name := range names {
+		if len(name) > col {
+			col = len(name)
+		}
+	}
+	if col > helpMaxColumn {
+		col = helpMaxColumn
+	}
+	return col
+}
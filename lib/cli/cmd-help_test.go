@@ -0,0 +1,172 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/digaverse/howi/pkg/log"
+)
+
+func TestHelpGlobal(t *testing.T) {
+	app := newTestApp(t)
+	var buf bytes.Buffer
+	help := HelpGlobal{
+		Project:  app.Project,
+		Commands: app.commands,
+		Flags:    visibleFlags(app.flags),
+	}
+	help.Print(log.New(&buf, log.NOTICE))
+	out := buf.String()
+	if strings.Contains(out, "\033[") {
+		t.Errorf("help should not contain ANSI sequences when colors are disabled got:\n%s", out)
+	}
+	if strings.Contains(out, "secret") {
+		t.Error("hidden command should not be listed")
+	}
+	for _, want := range []string{"  deploy      deploy the application", "  --help, -h     display help", "INTERNAL\n  about-howi"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("help should contain %q got:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "--debug") > strings.Index(out, "--verbose") {
+		t.Errorf("flags should be sorted by name got:\n%s", out)
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	app := newTestApp(t)
+	cmd := app.commands["deploy"]
+	cmd.SetLongDesc(strings.Repeat("word ", 30))
+
+	var buf bytes.Buffer
+	help := HelpCommand{Project: app.Project, Command: cmd}
+	help.Print(log.New(&buf, log.NOTICE))
+	out := buf.String()
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 80 {
+			t.Errorf("line exceeds terminal width %q", line)
+		}
+	}
+	for _, want := range []string{"  app deploy [flags] [subcommands]", "  rollback  rollback last deployment",
+		"  --target, -t  deployment target", "EXAMPLES\n  Deploy to production\n    $ app --verbose deploy -t=prod",
+		"SEE ALSO\n  app about-howi"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("help should contain %q got:\n%s", want, out)
+		}
+	}
+}
+
+func TestHelpTemplateOverride(t *testing.T) {
+	app := newTestApp(t)
+	cmd := app.commands["deploy"]
+	var buf bytes.Buffer
+	help := HelpCommand{Project: app.Project, Command: cmd, Template: "app: {{ .Usage }}"}
+	help.Print(log.New(&buf, log.NOTICE))
+	if got := strings.TrimSpace(buf.String()); got != "app: app deploy [flags] [subcommands]" {
+		t.Errorf("application template want used got %q", got)
+	}
+
+	buf.Reset()
+	cmd.SetHelpTemplate("cmd: {{ .Command.Name }}")
+	help = HelpCommand{Project: app.Project, Command: cmd, Template: "app: {{ .Usage }}"}
+	help.Print(log.New(&buf, log.NOTICE))
+	if got := strings.TrimSpace(buf.String()); got != "cmd: deploy" {
+		t.Errorf("command template want used got %q", got)
+	}
+}
+
+func TestTmplWrap(t *testing.T) {
+	p := TmplParser{}
+	p.SetOutput(false, 30)
+	got := p.wrap(4, "the quick brown fox jumps over the lazy dog")
+	want := "the quick brown fox jumps\n    over the lazy dog"
+	if got != want {
+		t.Errorf("wrap want %q got %q", want, got)
+	}
+}
+
+// runHelp runs help command with given args and returns its output.
+func runHelp(t *testing.T, app *Application, args ...string) (string, *Worker) {
+	app.unsetFlags()
+	cmd := app.commands["help"]
+	args = append([]string{"help"}, args...)
+	if err := cmd.parse(&args); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := newWorker(app.Project, cmd.getArgs(), log.New(&buf, log.NOTICE))
+	w.setPhase("do")
+	for _, flag := range cmd.getFlags() {
+		w.attachFlag(flag)
+	}
+	cmd.doFn(w)
+	return buf.String(), w
+}
+
+func TestHelpCommandLookup(t *testing.T) {
+	app := newTestApp(t)
+	app.AddHelpTopic("environment", "Environment variables", "APP_TARGET sets default deployment target.")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"global", nil, []string{"The commands are:", "Additional help topics:\n  environment  Environment variables"}},
+		{"command", []string{"deploy"}, []string{"app deploy [flags] [subcommands]"}},
+		{"subcommand", []string{"deploy", "rollback"}, []string{"app deploy rollback [args]", "$ app deploy rollback v1.0.0"}},
+		{"all", []string{"--all"}, []string{"  deploy      deploy the application\n    rollback  rollback last deployment"}},
+		{"topics", []string{"topics"}, []string{"HELP TOPICS\n  environment  Environment variables"}},
+		{"topic", []string{"environment"}, []string{"Environment variables\n\nAPP_TARGET sets default deployment target."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, w := runHelp(t, app, tt.args...)
+			if w.Failed() {
+				t.Fatalf("help failed: %s", w.Phase().message())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("help should contain %q got:\n%s", want, out)
+				}
+			}
+			if strings.Contains(out, "secret") {
+				t.Error("hidden command should not be displayed")
+			}
+		})
+	}
+	for _, args := range [][]string{{"secret"}, {"deploy", "upgrade"}, {"nope"}} {
+		if _, w := runHelp(t, app, args...); !w.Failed() {
+			t.Errorf("help %q should fail", args)
+		}
+	}
+}
+
+func TestHelpTopicNames(t *testing.T) {
+	tests := []struct {
+		topic    string
+		wantCode int
+	}{
+		{"environment", 0},
+		{"deploy", 2},
+		{"topics", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			app := newTestApp(t)
+			app.AddHelpTopic(tt.topic, "Topic", "")
+			code, out := runApp(t, app, "deploy")
+			if code != tt.wantCode {
+				t.Fatalf("exit code want %d got %d output:\n%s", tt.wantCode, code, out)
+			}
+			if want := `help topic "` + tt.topic + `" is already in use`; code != 0 && !strings.Contains(out, want) {
+				t.Errorf("output should contain %q got:\n%s", want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"github.com/digaverse/howi/lib/cli/flags"
+	"github.com/digaverse/howi/lib/update"
+)
+
+// SelfUpdate enables built-in "self-update" command which checks given
+// release feed for newer version of the application and replaces running
+// executable with verified release artifact.
+func (cli *Application) SelfUpdate(u *update.Updater) {
+	cli.AddCommand(cmdSelfUpdate(u))
+}
+
+func cmdSelfUpdate(u *update.Updater) Command {
+	cmd := NewCommand("self-update")
+	cmd.SetShortDesc("Update this application to the latest release")
+	cmd.SetCategory("internal")
+
+	check := flags.NewBoolFlag("check")
+	check.SetUsage("only check is newer version available")
+	cmd.AddFlag(check)
+
+	prerelease := flags.NewBoolFlag("prerelease")
+	prerelease.SetUsage("allow update to pre-release version")
+	cmd.AddFlag(prerelease)
+
+	cmd.AddExample("self-update --check", "Check is newer version available without updating")
+
+	cmd.Do(func(w *Worker) {
+		updater := *u
+		if f, _ := w.Flag("prerelease"); f.Present() {
+			updater.Prerelease = true
+		}
+		w.Log.Infof("checking updates from %s", updater.Source)
+		rel, err := updater.Check(w.Project.Version)
+		if err != nil {
+			w.Failf("update check failed: %s", err)
+			return
+		}
+		if rel == nil {
+			w.Log.Okf("%s %s is up to date", w.Project.Name, w.Project.Version)
+			return
+		}
+		w.Log.Noticef("new version %s is available (current %s)", rel.Version, w.Project.Version)
+		if rel.Notes != "" {
+			w.Log.Line(rel.Notes)
+		}
+		if f, _ := w.Flag("check"); f.Present() {
+			return
+		}
+		if err := updater.Apply(rel); err != nil {
+			w.Failf("update failed: %s", err)
+			return
+		}
+		w.Log.Okf("%s updated to %s", w.Project.Name, rel.Version)
+	})
+	return cmd
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/digaverse/howi/lib/update"
+)
+
+// newUpdateServer serves release feed at /feed.json and artifact for any
+// other path.
+func newUpdateServer(t *testing.T, artifact []byte, checksum string, versions ...string) *httptest.Server {
+	feed := update.Feed{Name: "app"}
+	for _, v := range versions {
+		feed.Releases = append(feed.Releases, update.Release{
+			Version: semver.MustParse(v),
+			Notes:   "release " + v,
+			Assets: []update.Asset{{
+				OS:     runtime.GOOS,
+				Arch:   runtime.GOARCH,
+				URL:    "app-" + v,
+				SHA256: checksum,
+			}},
+		})
+	}
+	data, err := json.Marshal(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/feed.json" {
+			w.Write(data)
+			return
+		}
+		w.Write(artifact)
+	}))
+}
+
+func TestSelfUpdate(t *testing.T) {
+	artifact := []byte("#!/bin/sh\necho new version\n")
+	sum := sha256.Sum256(artifact)
+	valid := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		args     []string
+		versions []string
+		checksum string
+		feed     string
+		wantCode int
+		wantOut  string
+		replaced bool
+	}{
+		{"up-to-date", nil, []string{"0.9.0", "1.0.0"}, valid, "/feed.json",
+			ExitOK, "app 1.0.0 is up to date", false},
+		{"check", []string{"--check"}, []string{"1.0.0", "1.1.0"}, valid, "/feed.json",
+			ExitOK, "new version 1.1.0 is available (current 1.0.0)", false},
+		{"ignore-prerelease", nil, []string{"1.1.0-rc.1"}, valid, "/feed.json",
+			ExitOK, "app 1.0.0 is up to date", false},
+		{"prerelease", []string{"--prerelease", "--check"}, []string{"1.1.0-rc.1"}, valid, "/feed.json",
+			ExitOK, "new version 1.1.0-rc.1 is available", false},
+		{"update", nil, []string{"1.1.0"}, valid, "/feed.json",
+			ExitOK, "app updated to 1.1.0", true},
+		{"checksum-mismatch", nil, []string{"1.1.0"}, strings.Repeat("0", 64), "/feed.json",
+			ExitFailure, "update failed: checksum mismatch", false},
+		{"feed-not-found", nil, []string{"1.1.0"}, valid, "/missing.json",
+			ExitFailure, "update check failed", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newUpdateServer(t, artifact, tt.checksum, tt.versions...)
+			defer srv.Close()
+			dir, err := ioutil.TempDir("", "howi-self-update")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			exe := filepath.Join(dir, "app")
+			if err := ioutil.WriteFile(exe, []byte("old"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			app := newTestApp(t)
+			app.Project.Version = semver.MustParse("1.0.0")
+			app.SelfUpdate(&update.Updater{Source: srv.URL + tt.feed, Executable: exe})
+			code, out := runApp(t, app, append([]string{"self-update"}, tt.args...)...)
+			if code != tt.wantCode {
+				t.Errorf("exit code want %d got %d output:\n%s", tt.wantCode, code, out)
+			}
+			if !strings.Contains(out, tt.wantOut) {
+				t.Errorf("output should contain %q got:\n%s", tt.wantOut, out)
+			}
+			got, _ := ioutil.ReadFile(exe)
+			if replaced := string(got) == string(artifact); replaced != tt.replaced {
+				t.Errorf("executable replaced = %t, want %t", replaced, tt.replaced)
+			}
+		})
+	}
+}
@@ -31,10 +31,20 @@ type Command struct {
 	subCommands    map[string]Command      // subcommands
 	flags          map[int]flags.Interface // command flags
 	flagAliases    map[string]int          // command flag aliases
+	persistent     map[int]bool            // flags inherited by subcommands
 	acceptArgs     int
 	args           []vars.Value
 	subCmd         *Command // if subcommand was called
 	parents        []string
+	examples       []Example
+	seeAlso        []string
+	helpTmpl       string // overrides application command help template
+}
+
+// Example of command invocation displayed in help output.
+type Example struct {
+	Cmd  string // command line following the application name
+	Desc string // what example does
 }
 
 // Hide the command from help menu and bash completion.
@@ -69,6 +79,20 @@ func (c *Command) AddFlag(f flags.Interface) {
 	}
 }
 
+// AddPersistentFlag adds provided flag to command which is also accepted by
+// all subcommands below this command. Persistent flag is parsed regardless of
+// its position after the command and subcommands can read it from Worker.
+func (c *Command) AddPersistentFlag(f flags.Interface) {
+	c.AddFlag(f)
+	if !c.errs.Nil() {
+		return
+	}
+	if c.persistent == nil {
+		c.persistent = make(map[int]bool)
+	}
+	c.persistent[len(c.flags)] = true
+}
+
 // SetCategory sets help category to categorize commands in help output
 func (c *Command) SetCategory(category string) {
 	c.category = strings.TrimSpace(category)
@@ -98,6 +122,30 @@ func (c *Command) SetLongDesc(desc string) {
 	c.longDesc = desc
 }
 
+// AddExample adds example invocation of the command. Arg cmd is command
+// line without application name e.g. "deploy --target=prod" and desc
+// describes what the example does.
+func (c *Command) AddExample(cmd, desc string) {
+	c.examples = append(c.examples, Example{
+		Cmd:  strings.TrimSpace(cmd),
+		Desc: strings.TrimSpace(desc),
+	})
+}
+
+// AddSeeAlso adds references to related commands. Reference is command
+// path without application name e.g. "deploy rollback".
+func (c *Command) AddSeeAlso(cmds ...string) {
+	for _, cmd := range cmds {
+		c.seeAlso = append(c.seeAlso, strings.Join(strings.Fields(cmd), " "))
+	}
+}
+
+// SetHelpTemplate overrides template used to display help for this command.
+// Template receives *HelpCommand as data.
+func (c *Command) SetHelpTemplate(tmpl string) {
+	c.helpTmpl = tmpl
+}
+
 // Name returns name of the command
 func (c *Command) Name() string {
 	if c.subCmd != nil {
@@ -133,6 +181,30 @@ func (c *Command) LongDesc() string {
 	return c.longDesc
 }
 
+// Examples returns commands examples
+func (c *Command) Examples() []Example {
+	if c.subCmd != nil {
+		return c.subCmd.Examples()
+	}
+	return c.examples
+}
+
+// SeeAlso returns references to related commands
+func (c *Command) SeeAlso() []string {
+	if c.subCmd != nil {
+		return c.subCmd.SeeAlso()
+	}
+	return c.seeAlso
+}
+
+// HelpTemplate returns help template set for the command if any
+func (c *Command) HelpTemplate() string {
+	if c.subCmd != nil {
+		return c.subCmd.HelpTemplate()
+	}
+	return c.helpTmpl
+}
+
 // AcceptsFlags returns true if command accepts any flags
 func (c *Command) AcceptsFlags() bool {
 	if c.subCmd != nil {
@@ -158,15 +230,12 @@ func (c *Command) HasSubcommands() bool {
 }
 
 // GetSubcommands returns slice with all subcommands for the command
+// sorted by name
 func (c *Command) GetSubcommands() []Command {
 	if c.subCmd != nil {
 		return c.subCmd.GetSubcommands()
 	}
-	var scmd []Command
-	for _, cmd := range c.subCommands {
-		scmd = append(scmd, cmd)
-	}
-	return scmd
+	return sortedCommands(c.subCommands)
 }
 
 // Before is first function called if it is set.
@@ -208,6 +277,41 @@ func (c *Command) AfterAlways(f func(w *Worker)) {
 	c.afterAlwaysFn = f
 }
 
+// BeforeE is same as Before, but returned error marks phase as failed.
+func (c *Command) BeforeE(f func(w *Worker) error) {
+	c.beforeFn = failOnError(f)
+}
+
+// DoE is same as Do, but returned error marks phase as failed. Returning
+// *ExitError makes application exit with its exit code.
+func (c *Command) DoE(f func(w *Worker) error) {
+	c.doFn = failOnError(f)
+}
+
+// AfterFailureE is same as AfterFailure, but returned error marks phase as failed.
+func (c *Command) AfterFailureE(f func(w *Worker) error) {
+	c.afterFailureFn = failOnError(f)
+}
+
+// AfterSuccessE is same as AfterSuccess, but returned error marks phase as failed.
+func (c *Command) AfterSuccessE(f func(w *Worker) error) {
+	c.afterSuccessFn = failOnError(f)
+}
+
+// AfterAlwaysE is same as AfterAlways, but returned error marks phase as failed.
+func (c *Command) AfterAlwaysE(f func(w *Worker) error) {
+	c.afterAlwaysFn = failOnError(f)
+}
+
+// failOnError converts error returning phase function to phase function.
+func failOnError(f func(w *Worker) error) func(w *Worker) {
+	return func(w *Worker) {
+		if err := f(w); err != nil {
+			w.FailErr(err)
+		}
+	}
+}
+
 // Parse command
 func (c *Command) parse(args *[]string) error {
 
@@ -217,12 +321,33 @@ func (c *Command) parse(args *[]string) error {
 
 	// remove name of this command
 	*args = (*args)[1:]
-	// command flags
+	// persistent flags are accepted anywhere below this command
 	for i := 1; i <= len(c.flags); i++ {
+		if !c.persistent[i] {
+			continue
+		}
 		if _, err := c.flags[i].Parse(args); err != nil {
 			return err
 		}
 	}
+	// other command flags are accepted only before subcommand
+	split := len(*args)
+	for i, arg := range *args {
+		if _, isSubcommand := c.subCommands[arg]; isSubcommand {
+			split = i
+			break
+		}
+	}
+	own := append([]string{}, (*args)[:split]...)
+	for i := 1; i <= len(c.flags); i++ {
+		if c.persistent[i] {
+			continue
+		}
+		if _, err := c.flags[i].Parse(&own); err != nil {
+			return err
+		}
+	}
+	*args = append(own, (*args)[split:]...)
 
 	// If we still have arg 0 which is not a argument or subcommand
 	if len(*args) > 0 && (*args)[0][0] == '-' {
@@ -249,16 +374,34 @@ func (c *Command) parse(args *[]string) error {
 	return nil
 }
 
-// get all already parsed flags for the worker
+// get all already parsed flags for the worker, those are flags of called
+// command or subcommand and persistent flags inherited from parent commands
 func (c *Command) getFlags() []flags.Interface {
-	var flags []flags.Interface
-	for _, flag := range c.flags {
-		flags = append(flags, flag)
+	if c.subCmd != nil {
+		return append(c.persistentFlags(), c.subCmd.getFlags()...)
 	}
+	return c.localFlags()
+}
+
+// localFlags returns flags of called command or subcommand without
+// inherited flags.
+func (c *Command) localFlags() []flags.Interface {
 	if c.subCmd != nil {
-		subFlags := c.subCmd.getFlags()
-		for _, flag := range subFlags {
-			flags = append(flags, flag)
+		return c.subCmd.localFlags()
+	}
+	var flags []flags.Interface
+	for i := 1; i <= len(c.flags); i++ {
+		flags = append(flags, c.flags[i])
+	}
+	return flags
+}
+
+// persistentFlags returns flags of this command inherited by subcommands.
+func (c *Command) persistentFlags() []flags.Interface {
+	var flags []flags.Interface
+	for i := 1; i <= len(c.flags); i++ {
+		if c.persistent[i] {
+			flags = append(flags, c.flags[i])
 		}
 	}
 	return flags
@@ -294,7 +437,11 @@ func (c *Command) getArgs() []vars.Value {
 
 // Verify ranges over command flags and the sub commands
 //   - verify that commands are valid and have atleast Do function
-//   - verify that subcommand do not shadow flags of any parent command
+//   - verify that subcommand do not shadow global flags or persistent flags
+//     of any parent command
+//
+// reservedFlags is not modified, each branch of the command tree gets its own
+// copy so that sibling commands can define flags with same name.
 func (c *Command) verify(reservedFlags map[string]int) error {
 	if !c.errs.Nil() {
 		return c.errs.AsError()
@@ -303,36 +450,40 @@ func (c *Command) verify(reservedFlags map[string]int) error {
 	if !namespace.IsValid(c.name) {
 		return errors.Newf(FmtErrCommandNameInvalid, c.name, namespace.NamespaceMustCompile)
 	}
-	// must have Do function
-	if c.doFn == nil {
-		if c.subCommands != nil {
-			goto SubCommands
+	// Check command flags, also of group commands so that their persistent
+	// flags are not shadowed by subcommands
+	reserved := copyFlagAliases(reservedFlags)
+	for flagAlias, flagID := range c.flagAliases {
+		if _, isReserved := reservedFlags[flagAlias]; isReserved {
+			return errors.Newf(FmtErrCommandFlagShadowing, c.name, c.flags[flagID].Name(), flagAlias)
 		}
-		return errors.Newf(FmtErrCommandMissingDoFn, c.name)
-	}
-	// Check command flags
-	if c.flagAliases != nil && c.flags != nil {
-		for flagAlias, flagID := range c.flagAliases {
-			if _, isReserved := reservedFlags[flagAlias]; isReserved {
-				return errors.Newf(FmtErrCommandFlagShadowing, c.name, c.flags[flagID].Name(), flagAlias)
-			}
-			// Add it as reserved for subcommands
-			reservedFlags[flagAlias] = flagID
+		// Add persistent flags as reserved for subcommands
+		if c.persistent[flagID] {
+			reserved[flagAlias] = flagID
 		}
 	}
-SubCommands:
+	// must have Do function unless it is group of subcommands
+	if c.doFn == nil && c.subCommands == nil {
+		return errors.Newf(FmtErrCommandMissingDoFn, c.name)
+	}
 	// Check subcommand flags if any
-	if c.subCommands != nil {
-		for _, cmd := range c.subCommands {
-			err := cmd.verify(reservedFlags)
-			if err != nil {
-				return err
-			}
+	for _, cmd := range c.subCommands {
+		if err := cmd.verify(reserved); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// copyFlagAliases returns copy of flag aliases map.
+func copyFlagAliases(aliases map[string]int) map[string]int {
+	cp := make(map[string]int, len(aliases))
+	for alias, id := range aliases {
+		cp[alias] = id
+	}
+	return cp
+}
+
 func (c *Command) attachFlag(f flags.Interface) {
 	if c.flags == nil {
 		c.flags = make(map[int]flags.Interface)
@@ -350,94 +501,93 @@ func (c *Command) attachFlag(f flags.Interface) {
 	}
 }
 
-// execute Before function if it was set.
-func (c *Command) executeBeforeFn(w *Worker) {
-	w.phase = "before"
-	if c.subCmd != nil {
-		c.subCmd.executeBeforeFn(w)
-		return
+// hookChain returns commands which Before and After hooks are executed for
+// called command. Without chaining it is only called command or subcommand,
+// with chaining parent commands are included in order from top to bottom.
+func (c *Command) hookChain(chained bool) []*Command {
+	if c.subCmd == nil {
+		return []*Command{c}
 	}
+	chain := c.subCmd.hookChain(chained)
+	if chained {
+		chain = append([]*Command{c}, chain...)
+	}
+	return chain
+}
 
-	if c.beforeFn == nil {
-		w.phases[w.phase].status = StatusSkipped
-		w.Log.Debug(w.phase, " skipped")
+// executeHooks executes hook of phase for each command in chain in order.
+// Tasks of each level are waited before next level is executed and failure
+// at any level aborts executing rest of the chain.
+func executeHooks(w *Worker, phase string, chain []*Command, hook func(c *Command) func(w *Worker)) {
+	w.setPhase(phase)
+	var levels []*Command
+	for _, c := range chain {
+		if hook(c) != nil {
+			levels = append(levels, c)
+		}
+	}
+	if len(levels) == 0 {
+		w.Phase().skip()
+		w.Log.Debug(phase, " skipped")
 		return
 	}
-	w.Phase().start()
-	c.beforeFn(w)
+	w.startPhase()
+	for _, c := range levels {
+		w.Log.Debugf("phase: %s command: %s", phase, c.name)
+		w.call(hook(c))
+		w.wg.Wait()
+		if w.Failed() {
+			w.Log.Debugf("phase: %s aborted by command: %s", phase, c.name)
+			break
+		}
+	}
 	// wait
 	w.phasewait()
 }
 
+// reverseChain returns hook chain in reverse order used to unwind After hooks.
+func reverseChain(chain []*Command) []*Command {
+	reversed := make([]*Command, len(chain))
+	for i, c := range chain {
+		reversed[len(chain)-1-i] = c
+	}
+	return reversed
+}
+
+// execute Before functions of hook chain.
+func executeBeforeFn(w *Worker, chain []*Command) {
+	executeHooks(w, "before", chain, func(c *Command) func(w *Worker) { return c.beforeFn })
+}
+
 // execute Do function.
 func (c *Command) executeDoFn(w *Worker) {
-	w.phase = "do"
+	w.setPhase("do")
 	if c.subCmd != nil {
 		c.subCmd.executeDoFn(w)
 		return
 	}
-	w.Phase().start()
+	w.startPhase()
 	if c.doFn == nil {
 		w.Log.Line(c.ShortDesc())
 		w.Failf(FmtErrCommandNotProvided, c.Name())
-		return
+	} else {
+		w.call(c.doFn)
 	}
-	c.doFn(w)
 	// wait
 	w.phasewait()
 }
 
-// execute AfterFailure function.
-func (c *Command) executeAfterFailureFn(w *Worker) {
-	w.phase = "after-failure"
-	if c.subCmd != nil {
-		c.subCmd.executeAfterFailureFn(w)
-		return
-	}
-	if c.afterFailureFn == nil {
-		w.phases[w.phase].status = StatusSkipped
-		w.Log.Debug(w.phase, " skipped")
-		return
-	}
-	w.Phase().start()
-	c.afterFailureFn(w)
-	// wait
-	w.phasewait()
+// execute AfterFailure functions of hook chain in reverse order.
+func executeAfterFailureFn(w *Worker, chain []*Command) {
+	executeHooks(w, "after-failure", reverseChain(chain), func(c *Command) func(w *Worker) { return c.afterFailureFn })
 }
 
-// execute AfterSuccess function.
-func (c *Command) executeAfterSuccessFn(w *Worker) {
-	w.phase = "after-success"
-	if c.subCmd != nil {
-		c.subCmd.executeAfterSuccessFn(w)
-		return
-	}
-	if c.afterSuccessFn == nil {
-		w.phases[w.phase].status = StatusSkipped
-		w.Log.Debug(w.phase, " skipped")
-		return
-	}
-	w.Phase().start()
-	c.afterSuccessFn(w)
-	// wait
-	w.phasewait()
+// execute AfterSuccess functions of hook chain in reverse order.
+func executeAfterSuccessFn(w *Worker, chain []*Command) {
+	executeHooks(w, "after-success", reverseChain(chain), func(c *Command) func(w *Worker) { return c.afterSuccessFn })
 }
 
-// execute AfterAlways function.
-func (c *Command) executeAfterAlwaysFn(w *Worker) {
-	w.phase = "after-always"
-	if c.subCmd != nil {
-		c.subCmd.executeAfterAlwaysFn(w)
-		return
-	}
-
-	if c.afterAlwaysFn == nil {
-		w.phases[w.phase].status = StatusSkipped
-		w.Log.Debug(w.phase, " skipped")
-		return
-	}
-	w.Phase().start()
-	c.afterAlwaysFn(w)
-	// wait
-	w.phasewait()
+// execute AfterAlways functions of hook chain in reverse order.
+func executeAfterAlwaysFn(w *Worker, chain []*Command) {
+	executeHooks(w, "after-always", reverseChain(chain), func(c *Command) func(w *Worker) { return c.afterAlwaysFn })
 }
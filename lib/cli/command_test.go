@@ -0,0 +1,136 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/digaverse/howi/lib/cli/flags"
+	"github.com/digaverse/howi/pkg/log"
+)
+
+func TestPersistentFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    string
+		wantErr string
+		want    map[string]string // flags available for worker and their values
+		missing []string          // flags not available for worker
+	}{
+		{"after-subcommand", "deploy rollback --env=prod v1", "",
+			map[string]string{"env": "prod"}, []string{"target"}},
+		{"before-subcommand", "deploy --env=prod rollback", "",
+			map[string]string{"env": "prod"}, []string{"target"}},
+		{"local-before-subcommand", "deploy -t=eu rollback", "",
+			map[string]string{"env": ""}, []string{"target"}},
+		{"local-after-subcommand", "deploy rollback --target=eu", `unknown flag "--target=eu" for command "rollback"`, nil, nil},
+		{"command", "deploy --env=dev --target=eu", "",
+			map[string]string{"env": "dev", "target": "eu"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t)
+			cmd := app.commands["deploy"]
+			args := strings.Fields(tt.args)
+			err := cmd.parse(&args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parse want error %q got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := newWorker(app.Project, cmd.getArgs(), log.New(&bytes.Buffer{}, log.NOTICE))
+			for _, flag := range cmd.getFlags() {
+				w.attachFlag(flag)
+			}
+			for name, value := range tt.want {
+				flag, err := w.Flag(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := flag.Value().String(); got != value {
+					t.Errorf("flag %q want %q got %q", name, value, got)
+				}
+			}
+			for _, name := range tt.missing {
+				if _, err := w.Flag(name); err == nil {
+					t.Errorf("flag %q should not be available for worker", name)
+				}
+			}
+		})
+	}
+}
+
+func TestPersistentFlagShadowing(t *testing.T) {
+	for _, withDo := range []bool{true, false} {
+		group := NewCommand("release")
+		group.AddPersistentFlag(flags.NewStringFlag("env"))
+		if withDo {
+			group.Do(func(w *Worker) {})
+		}
+		publish := NewCommand("publish")
+		publish.AddFlag(flags.NewStringFlag("env"))
+		publish.Do(func(w *Worker) {})
+		group.AddSubcommand(publish)
+		want := `command (publish) flag "env" alias "env" shadows existing flag`
+		if err := group.verify(map[string]int{}); err == nil || err.Error() != want {
+			t.Errorf("command with do function %t: want error %q got %v", withDo, want, err)
+		}
+	}
+}
+
+func TestSiblingCommandFlags(t *testing.T) {
+	app := newTestApp(t)
+	build := NewCommand("build")
+	build.AddFlag(flags.NewStringFlag("target", "t"))
+	build.AddFlag(flags.NewStringFlag("env"))
+	build.Do(func(w *Worker) {})
+	app.AddCommand(build)
+	if code, out := runApp(t, app, "build", "--target=eu"); code != 0 {
+		t.Fatalf("sibling commands should share flag names got %d output:\n%s", code, out)
+	}
+	for _, name := range []string{"target", "t", "env"} {
+		if _, exists := app.flagAliases[name]; exists {
+			t.Errorf("command flag %q should not be added to global flags", name)
+		}
+	}
+}
+
+func TestPersistentFlagsHelp(t *testing.T) {
+	app := newTestApp(t)
+	out, w := runHelp(t, app, "deploy", "rollback")
+	if w.Failed() {
+		t.Fatal(w.Phase().message())
+	}
+	if !strings.Contains(out, "Inherited flags:\n  --env  deployment environment") {
+		t.Errorf("help should list inherited flags got:\n%s", out)
+	}
+	if strings.Contains(out, "--target") {
+		t.Errorf("help should not list parent local flags got:\n%s", out)
+	}
+	out, _ = runHelp(t, app, "deploy")
+	if strings.Contains(out, "Inherited flags") {
+		t.Errorf("top level command has no inherited flags got:\n%s", out)
+	}
+}
+
+func TestExecuteDoFnWithoutDo(t *testing.T) {
+	var out bytes.Buffer
+	w := newTestWorker()
+	w.Log = log.New(&out, log.DEBUG)
+	cmd := NewCommand("release")
+	cmd.executeDoFn(w)
+	if got := w.Phase().Status(); got != "failed" {
+		t.Errorf("phase status want failed got %s", got)
+	}
+	if !strings.Contains(out.String(), "phase: do status: failed, elapsed:") {
+		t.Errorf("phase should be finished like any other do phase got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,659 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/digaverse/howi/lib/cli/flags"
+	"github.com/digaverse/howi/pkg/errors"
+	"github.com/digaverse/howi/pkg/project"
+)
+
+const (
+	// DocsMan generates man(1) page per command.
+	DocsMan = "man"
+	// DocsMarkdown generates Markdown page per command.
+	DocsMarkdown = "markdown"
+	// DocsReference generates single page Markdown reference.
+	DocsReference = "reference"
+	// DocsRST generates reStructuredText page per command.
+	DocsRST = "rst"
+	// FmtErrUnknownDocsFormat formats error for unsupported docs format.
+	FmtErrUnknownDocsFormat = "unknown docs format %q"
+)
+
+// Docs generates documentation from application command tree and project
+// metadata. Hidden commands and flags are not documented.
+type Docs struct {
+	Project  *project.Project
+	Flags    []flags.Interface
+	Commands []*DocsCommand
+	date     time.Time
+	index    map[string]*DocsCommand
+}
+
+// DocsCommand is command within documented command tree.
+type DocsCommand struct {
+	Command     Command
+	Path        []string // application name, parent commands and command name
+	Parent      *DocsCommand
+	Subcommands []*DocsCommand
+}
+
+// Title returns full command path e.g. "app cmd subcmd".
+func (dc *DocsCommand) Title() string {
+	return strings.Join(dc.Path, " ")
+}
+
+// Usage returns usage line of the command.
+func (dc *DocsCommand) Usage() string {
+	return commandUsage(dc.Path, dc.Command)
+}
+
+// Flags returns visible flags of the command.
+func (dc *DocsCommand) Flags() []flags.Interface {
+	return visibleFlags(dc.Command.flags)
+}
+
+// InheritedFlags returns visible persistent flags of parent commands.
+func (dc *DocsCommand) InheritedFlags() []flags.Interface {
+	var inherited []flags.Interface
+	for p := dc.Parent; p != nil; p = p.Parent {
+		var visible []flags.Interface
+		for _, f := range p.Command.persistentFlags() {
+			if !f.IsHidden() {
+				visible = append(visible, f)
+			}
+		}
+		inherited = append(visible, inherited...)
+	}
+	return inherited
+}
+
+// Docs returns documentation generator for the application.
+func (cli *Application) Docs() *Docs {
+	d := &Docs{
+		Project: cli.Project,
+		Flags:   visibleFlags(cli.flags),
+		date:    cli.Project.BuildDate,
+	}
+	if d.date.IsZero() {
+		d.date = time.Now()
+	}
+	for _, cmd := range sortedCommands(cli.commands) {
+		if cmd.hidden || cmd.name == cli.Project.Name {
+			continue
+		}
+		d.Commands = append(d.Commands, newDocsCommand(cmd, []string{cli.Project.Name}, nil))
+	}
+	d.index = make(map[string]*DocsCommand)
+	d.Walk(func(dc *DocsCommand) error {
+		d.index[dc.Title()] = dc
+		return nil
+	})
+	return d
+}
+
+// SeeAlso returns documented commands referenced by dc, references to
+// unknown or hidden commands are ignored.
+func (d *Docs) SeeAlso(dc *DocsCommand) []*DocsCommand {
+	var related []*DocsCommand
+	for _, ref := range dc.Command.seeAlso {
+		if r, ok := d.index[d.Project.Name+" "+ref]; ok {
+			related = append(related, r)
+		}
+	}
+	return related
+}
+
+// GenerateDocs writes documentation of given format into directory dir.
+// Format must be one of DocsMan, DocsMarkdown, DocsRST or DocsReference.
+func (cli *Application) GenerateDocs(format, dir string) error {
+	d := cli.Docs()
+	switch format {
+	case DocsMan:
+		return d.WriteMan(dir)
+	case DocsMarkdown:
+		return d.WriteMarkdown(dir)
+	case DocsRST:
+		return d.WriteRST(dir)
+	case DocsReference:
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := d.WriteReference(&buf); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(dir, d.Project.Name+".md"), buf.Bytes(), 0644)
+	}
+	return errors.Newf(FmtErrUnknownDocsFormat, format)
+}
+
+// Walk calls fn for each documented command in depth first order.
+func (d *Docs) Walk(fn func(dc *DocsCommand) error) error {
+	var walk func(cmds []*DocsCommand) error
+	walk = func(cmds []*DocsCommand) error {
+		for _, dc := range cmds {
+			if err := fn(dc); err != nil {
+				return err
+			}
+			if err := walk(dc.Subcommands); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(d.Commands)
+}
+
+// WriteMan writes man(1) page for the application and each command into dir.
+func (d *Docs) WriteMan(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	d.manApp(&buf)
+	if err := ioutil.WriteFile(filepath.Join(dir, d.Project.Name+".1"), buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return d.Walk(func(dc *DocsCommand) error {
+		buf.Reset()
+		d.manCommand(&buf, dc)
+		return ioutil.WriteFile(filepath.Join(dir, manName(dc.Path)+".1"), buf.Bytes(), 0644)
+	})
+}
+
+// WriteMarkdown writes Markdown page for the application and each command
+// into dir. Pages are cross-linked with each other.
+func (d *Docs) WriteMarkdown(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	d.markdownApp(&buf, markdownFile, 1)
+	if err := ioutil.WriteFile(filepath.Join(dir, markdownFile([]string{d.Project.Name})), buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return d.Walk(func(dc *DocsCommand) error {
+		buf.Reset()
+		d.markdownCommand(&buf, dc, markdownFile, 1)
+		return ioutil.WriteFile(filepath.Join(dir, markdownFile(dc.Path)), buf.Bytes(), 0644)
+	})
+}
+
+// WriteRST writes reStructuredText page for the application and each command
+// into dir. Pages are cross-linked with each other using :ref: roles.
+func (d *Docs) WriteRST(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	d.rstApp(&buf)
+	if err := ioutil.WriteFile(filepath.Join(dir, d.Project.Name+".rst"), buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return d.Walk(func(dc *DocsCommand) error {
+		buf.Reset()
+		d.rstCommand(&buf, dc)
+		return ioutil.WriteFile(filepath.Join(dir, rstLabel(dc.Path)+".rst"), buf.Bytes(), 0644)
+	})
+}
+
+// WriteReference writes single page Markdown reference of all commands to w.
+func (d *Docs) WriteReference(w io.Writer) error {
+	var buf bytes.Buffer
+	d.markdownApp(&buf, markdownAnchor, 1)
+	err := d.Walk(func(dc *DocsCommand) error {
+		buf.WriteString("\n")
+		d.markdownCommand(&buf, dc, markdownAnchor, 2)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (d *Docs) markdownApp(buf *bytes.Buffer, link func([]string) string, level int) {
+	h := strings.Repeat("#", level)
+	fmt.Fprintf(buf, "%s %s\n\n", h, d.Project.Name)
+	if d.Project.Title != "" {
+		fmt.Fprintf(buf, "**%s**\n\n", d.Project.Title)
+	}
+	if d.Project.Description != "" {
+		fmt.Fprintf(buf, "%s\n\n", d.Project.Description)
+	}
+	fmt.Fprintf(buf, "%s# Synopsis\n\n```\n%s [global-flags] command [command-flags] [arguments]\n```\n\n", h, d.Project.Name)
+	if len(d.Commands) > 0 {
+		fmt.Fprintf(buf, "%s# Commands\n\n", h)
+		for _, dc := range d.Commands {
+			fmt.Fprintf(buf, "* [%s](%s) - %s\n", dc.Title(), link(dc.Path), dc.Command.shortDesc)
+		}
+		buf.WriteString("\n")
+	}
+	d.markdownFlags(buf, h+"# Global flags", d.Flags)
+	d.markdownProject(buf, h)
+}
+
+func (d *Docs) markdownCommand(buf *bytes.Buffer, dc *DocsCommand, link func([]string) string, level int) {
+	h := strings.Repeat("#", level)
+	fmt.Fprintf(buf, "%s %s\n\n", h, dc.Title())
+	if dc.Command.shortDesc != "" {
+		fmt.Fprintf(buf, "%s\n\n", dc.Command.shortDesc)
+	}
+	fmt.Fprintf(buf, "%s# Synopsis\n\n```\n%s\n", h, dc.Usage())
+	if dc.Command.usage != "" {
+		fmt.Fprintf(buf, "%s\n", dc.Command.usage)
+	}
+	buf.WriteString("```\n\n")
+	if dc.Command.longDesc != "" {
+		fmt.Fprintf(buf, "%s# Description\n\n%s\n\n", h, dc.Command.longDesc)
+	}
+	d.markdownFlags(buf, h+"# Flags", dc.Flags())
+	d.markdownFlags(buf, h+"# Inherited flags", dc.InheritedFlags())
+	if len(dc.Subcommands) > 0 {
+		fmt.Fprintf(buf, "%s# Subcommands\n\n", h)
+		for _, sub := range dc.Subcommands {
+			fmt.Fprintf(buf, "* [%s](%s) - %s\n", sub.Title(), link(sub.Path), sub.Command.shortDesc)
+		}
+		buf.WriteString("\n")
+	}
+	if len(dc.Command.examples) > 0 {
+		fmt.Fprintf(buf, "%s# Examples\n\n", h)
+		for _, ex := range dc.Command.examples {
+			if ex.Desc != "" {
+				fmt.Fprintf(buf, "%s\n\n", ex.Desc)
+			}
+			fmt.Fprintf(buf, "```\n$ %s %s\n```\n\n", d.Project.Name, ex.Cmd)
+		}
+	}
+	fmt.Fprintf(buf, "%s# See also\n\n", h)
+	if dc.Parent != nil {
+		fmt.Fprintf(buf, "* [%s](%s) - %s\n", dc.Parent.Title(), link(dc.Parent.Path), dc.Parent.Command.shortDesc)
+	} else {
+		fmt.Fprintf(buf, "* [%s](%s) - global flags and commands\n", d.Project.Name, link([]string{d.Project.Name}))
+	}
+	for _, r := range d.SeeAlso(dc) {
+		fmt.Fprintf(buf, "* [%s](%s) - %s\n", r.Title(), link(r.Path), r.Command.shortDesc)
+	}
+}
+
+func (d *Docs) markdownFlags(buf *bytes.Buffer, heading string, flags []flags.Interface) {
+	if len(flags) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "%s\n\n| Flag | Aliases | Description |\n| --- | --- | --- |\n", heading)
+	for _, f := range flags {
+		fmt.Fprintf(buf, "| `%s` | %s | %s |\n", f.HelpName(),
+			markdownCode(f.HelpAliases()), strings.Replace(f.Usage(), "|", "\\|", -1))
+	}
+	buf.WriteString("\n")
+}
+
+func (d *Docs) markdownProject(buf *bytes.Buffer, h string) {
+	var lines []string
+	if d.Project.Version.String() != "0.0.0" {
+		lines = append(lines, "Version: "+d.Project.Version.String())
+	}
+	if d.Project.Homepage != "" {
+		lines = append(lines, "Homepage: "+d.Project.Homepage)
+	}
+	if d.Project.Bugs.URL != "" {
+		lines = append(lines, "Bugs: "+d.Project.Bugs.URL)
+	}
+	if d.Project.License != "" {
+		lines = append(lines, "License: "+d.Project.License)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "%s# About\n\n", h)
+	for _, line := range lines {
+		fmt.Fprintf(buf, "* %s\n", line)
+	}
+	buf.WriteString("\n")
+}
+
+func (d *Docs) rstApp(buf *bytes.Buffer) {
+	rstHeading(buf, []string{d.Project.Name}, d.Project.Name)
+	if d.Project.Title != "" {
+		fmt.Fprintf(buf, "**%s**\n\n", d.Project.Title)
+	}
+	if d.Project.Description != "" {
+		fmt.Fprintf(buf, "%s\n\n", d.Project.Description)
+	}
+	rstSection(buf, "Synopsis")
+	fmt.Fprintf(buf, "::\n\n  %s [global-flags] command [command-flags] [arguments]\n\n", d.Project.Name)
+	if len(d.Commands) > 0 {
+		rstSection(buf, "Commands")
+		for _, dc := range d.Commands {
+			fmt.Fprintf(buf, "* %s - %s\n", rstRef(dc.Path), dc.Command.shortDesc)
+		}
+		buf.WriteString("\n")
+	}
+	d.rstFlags(buf, "Global flags", d.Flags)
+}
+
+func (d *Docs) rstCommand(buf *bytes.Buffer, dc *DocsCommand) {
+	rstHeading(buf, dc.Path, dc.Title())
+	if dc.Command.shortDesc != "" {
+		fmt.Fprintf(buf, "%s\n\n", dc.Command.shortDesc)
+	}
+	rstSection(buf, "Synopsis")
+	fmt.Fprintf(buf, "::\n\n  %s\n", dc.Usage())
+	if dc.Command.usage != "" {
+		fmt.Fprintf(buf, "  %s\n", dc.Command.usage)
+	}
+	buf.WriteString("\n")
+	if dc.Command.longDesc != "" {
+		rstSection(buf, "Description")
+		fmt.Fprintf(buf, "%s\n\n", dc.Command.longDesc)
+	}
+	d.rstFlags(buf, "Flags", dc.Flags())
+	d.rstFlags(buf, "Inherited flags", dc.InheritedFlags())
+	if len(dc.Subcommands) > 0 {
+		rstSection(buf, "Subcommands")
+		for _, sub := range dc.Subcommands {
+			fmt.Fprintf(buf, "* %s - %s\n", rstRef(sub.Path), sub.Command.shortDesc)
+		}
+		buf.WriteString("\n")
+	}
+	if len(dc.Command.examples) > 0 {
+		rstSection(buf, "Examples")
+		for _, ex := range dc.Command.examples {
+			if ex.Desc != "" {
+				fmt.Fprintf(buf, "%s\n\n", ex.Desc)
+			}
+			fmt.Fprintf(buf, "::\n\n  $ %s %s\n\n", d.Project.Name, ex.Cmd)
+		}
+	}
+	rstSection(buf, "See also")
+	if dc.Parent != nil {
+		fmt.Fprintf(buf, "* %s - %s\n", rstRef(dc.Parent.Path), dc.Parent.Command.shortDesc)
+	} else {
+		fmt.Fprintf(buf, "* %s - global flags and commands\n", rstRef([]string{d.Project.Name}))
+	}
+	for _, r := range d.SeeAlso(dc) {
+		fmt.Fprintf(buf, "* %s - %s\n", rstRef(r.Path), r.Command.shortDesc)
+	}
+}
+
+func (d *Docs) rstFlags(buf *bytes.Buffer, section string, flags []flags.Interface) {
+	if len(flags) == 0 {
+		return
+	}
+	rstSection(buf, section)
+	for _, f := range flags {
+		name := f.HelpName()
+		if aliases := f.HelpAliases(); aliases != "" {
+			name += ", " + aliases
+		}
+		fmt.Fprintf(buf, "``%s``\n  %s\n\n", name, f.Usage())
+	}
+}
+
+func (d *Docs) manHeader(buf *bytes.Buffer, path []string) {
+	fmt.Fprintf(buf, ".TH %s \"1\" %s %s %s\n",
+		manArg(strings.ToUpper(manName(path))), manArg(d.date.Format("January 2006")),
+		manArg(d.Project.Name+" "+d.Project.Version.String()), manArg(d.manTitle()))
+}
+
+func (d *Docs) manTitle() string {
+	if d.Project.Title != "" {
+		return d.Project.Title + " Manual"
+	}
+	return d.Project.Name + " Manual"
+}
+
+func (d *Docs) manApp(buf *bytes.Buffer) {
+	d.manHeader(buf, []string{d.Project.Name})
+	fmt.Fprintf(buf, ".SH NAME\n%s \\- %s\n", d.Project.Name, manEscape(firstSentence(d.Project.Description)))
+	fmt.Fprintf(buf, ".SH SYNOPSIS\n.B %s\n[global-flags] command [command-flags] [arguments]\n", d.Project.Name)
+	if d.Project.Description != "" {
+		fmt.Fprintf(buf, ".SH DESCRIPTION\n%s\n", manEscape(d.Project.Description))
+	}
+	if len(d.Commands) > 0 {
+		buf.WriteString(".SH COMMANDS\n")
+		for _, dc := range d.Commands {
+			fmt.Fprintf(buf, ".TP\n.B %s\n%s\n", manEscape(dc.Command.name), manEscape(dc.Command.shortDesc))
+		}
+	}
+	d.manFlags(buf, "GLOBAL OPTIONS", d.Flags)
+	d.manFooter(buf, nil, d.Commands)
+}
+
+func (d *Docs) manCommand(buf *bytes.Buffer, dc *DocsCommand) {
+	d.manHeader(buf, dc.Path)
+	fmt.Fprintf(buf, ".SH NAME\n%s \\- %s\n", manEscape(manName(dc.Path)), manEscape(dc.Command.shortDesc))
+	fmt.Fprintf(buf, ".SH SYNOPSIS\n.B %s\n", manEscape(dc.Usage()))
+	if dc.Command.usage != "" {
+		fmt.Fprintf(buf, ".br\n%s\n", manEscape(dc.Command.usage))
+	}
+	desc := dc.Command.longDesc
+	if desc == "" {
+		desc = dc.Command.shortDesc
+	}
+	if desc != "" {
+		fmt.Fprintf(buf, ".SH DESCRIPTION\n%s\n", manEscape(desc))
+	}
+	d.manFlags(buf, "OPTIONS", dc.Flags())
+	d.manFlags(buf, "INHERITED OPTIONS", dc.InheritedFlags())
+	d.manFlags(buf, "GLOBAL OPTIONS", d.Flags)
+	if len(dc.Command.examples) > 0 {
+		buf.WriteString(".SH EXAMPLES\n")
+		for _, ex := range dc.Command.examples {
+			if ex.Desc != "" {
+				fmt.Fprintf(buf, ".PP\n%s\n", manEscape(ex.Desc))
+			}
+			fmt.Fprintf(buf, ".PP\n.RS\n.nf\n$ %s %s\n.fi\n.RE\n", manEscape(d.Project.Name), manEscape(ex.Cmd))
+		}
+	}
+	related := []*DocsCommand{}
+	if dc.Parent != nil {
+		related = append(related, dc.Parent)
+	}
+	related = append(related, dc.Subcommands...)
+	related = append(related, d.SeeAlso(dc)...)
+	d.manFooter(buf, dc, related)
+}
+
+func (d *Docs) manFlags(buf *bytes.Buffer, section string, flags []flags.Interface) {
+	if len(flags) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, ".SH %s\n", section)
+	for _, f := range flags {
+		name := f.HelpName()
+		if aliases := f.HelpAliases(); aliases != "" {
+			name += ", " + aliases
+		}
+		fmt.Fprintf(buf, ".TP\n.B %s\n%s\n", manEscape(name), manEscape(f.Usage()))
+	}
+}
+
+func (d *Docs) manFooter(buf *bytes.Buffer, dc *DocsCommand, related []*DocsCommand) {
+	if author := d.Project.Author.String(); author != "" {
+		fmt.Fprintf(buf, ".SH AUTHOR\n%s\n", manEscape(author))
+	}
+	if d.Project.Bugs.URL != "" {
+		fmt.Fprintf(buf, ".SH REPORTING BUGS\n%s\n", manEscape(d.Project.Bugs.URL))
+	}
+	var refs []string
+	if dc != nil && dc.Parent == nil {
+		refs = append(refs, fmt.Sprintf(".BR %s (1)", d.Project.Name))
+	}
+	for _, r := range related {
+		refs = append(refs, fmt.Sprintf(".BR %s (1)", manEscape(manName(r.Path))))
+	}
+	if len(refs) > 0 {
+		fmt.Fprintf(buf, ".SH SEE ALSO\n%s\n", strings.Join(refs, ",\n"))
+	}
+}
+
+func newDocsCommand(cmd Command, parentPath []string, parent *DocsCommand) *DocsCommand {
+	dc := &DocsCommand{
+		Command: cmd,
+		Path:    append(append([]string{}, parentPath...), cmd.name),
+		Parent:  parent,
+	}
+	for _, sub := range sortedCommands(cmd.subCommands) {
+		if sub.hidden {
+			continue
+		}
+		dc.Subcommands = append(dc.Subcommands, newDocsCommand(sub, dc.Path, dc))
+	}
+	return dc
+}
+
+// commandUsage returns auto generated usage line for command with given path.
+func commandUsage(path []string, cmd Command) string {
+	usage := append([]string{}, path...)
+	if cmd.AcceptsFlags() {
+		usage = append(usage, "[flags]")
+	}
+	if cmd.HasSubcommands() {
+		usage = append(usage, "[subcommands]")
+	}
+	if cmd.AcceptsArgs() {
+		usage = append(usage, "[args]")
+	}
+	return strings.Join(usage, " ")
+}
+
+func sortedCommands(cmds map[string]Command) []Command {
+	var names []string
+	for name := range cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sorted []Command
+	for _, name := range names {
+		sorted = append(sorted, cmds[name])
+	}
+	return sorted
+}
+
+// visibleFlags returns flags which are not hidden ordered as they were added.
+func visibleFlags(m map[int]flags.Interface) []flags.Interface {
+	var list []flags.Interface
+	for i := 1; i <= len(m); i++ {
+		if f, ok := m[i]; ok && !f.IsHidden() {
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
+func manName(path []string) string {
+	return strings.Join(path, "-")
+}
+
+func markdownFile(path []string) string {
+	return strings.Join(path, "_") + ".md"
+}
+
+func markdownAnchor(path []string) string {
+	return "#" + strings.Join(path, "-")
+}
+
+func rstLabel(path []string) string {
+	return strings.Join(path, "_")
+}
+
+func rstRef(path []string) string {
+	return fmt.Sprintf(":ref:`%s <%s>`", strings.Join(path, " "), rstLabel(path))
+}
+
+func rstHeading(buf *bytes.Buffer, path []string, title string) {
+	line := strings.Repeat("=", len(title))
+	fmt.Fprintf(buf, ".. _%s:\n\n%s\n%s\n\n", rstLabel(path), title, line)
+}
+
+func rstSection(buf *bytes.Buffer, title string) {
+	fmt.Fprintf(buf, "%s\n%s\n\n", title, strings.Repeat("-", len(title)))
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+// manEscape escapes text for roff.
+func manEscape(s string) string {
+	s = strings.Replace(s, "\\", "\\e", -1)
+	s = strings.Replace(s, "-", "\\-", -1)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// manArg quotes s as argument of roff request.
+func manArg(s string) string {
+	s = strings.Replace(s, "\\", "\\e", -1)
+	s = strings.Replace(s, "\"", "\\(dq", -1)
+	s = strings.Replace(s, "\n", " ", -1)
+	return "\"" + s + "\""
+}
+
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i != -1 {
+		return s[:i+1]
+	}
+	return s
+}
+
+func cmdGenerateDocs(cli *Application) Command {
+	cmd := NewCommand("generate-docs")
+	cmd.SetShortDesc("Generate documentation of the application")
+	cmd.SetCategory("internal")
+	cmd.Hide()
+
+	format := flags.NewOptionFlag("format", []string{DocsMan, DocsMarkdown, DocsRST, DocsReference})
+	format.SetUsage("documentation format (man|markdown|rst|reference)")
+	format.Required()
+	cmd.AddFlag(format)
+
+	dir := flags.NewStringFlag("dir")
+	dir.SetUsage("directory where documentation is written. defaults to current directory")
+	cmd.AddFlag(dir)
+
+	cmd.AddExample("generate-docs --format=man --dir=man", "Write man pages into directory man")
+
+	cmd.Before(func(w *Worker) {
+		w.Config.ShowHeader = false
+		w.Config.ShowFooter = false
+	})
+	cmd.Do(func(w *Worker) {
+		format, _ := w.Flag("format")
+		dir, _ := w.Flag("dir")
+		out := dir.Value().String()
+		if out == "" {
+			out = "."
+		}
+		if err := cli.GenerateDocs(format.Value().String(), out); err != nil {
+			w.Fail(err.Error())
+			return
+		}
+		w.Log.Okf("%s documentation written to %s", format.Value(), out)
+	})
+	return cmd
+}
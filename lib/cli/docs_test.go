@@ -0,0 +1,170 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/digaverse/howi/lib/cli/flags"
+	"github.com/digaverse/howi/pkg/project"
+)
+
+func newTestApp(t *testing.T) *Application {
+	prj, err := project.New([]byte(`{
+		"name": "app",
+		"namespace": "howi",
+		"title": "Test App",
+		"description": "Application used in tests. It does nothing.",
+		"homepage": "https://example.com",
+		"bugs": {"url": "https://example.com/issues"},
+		"config": {"loglevel": 7}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := New(prj)
+
+	deploy := NewCommand("deploy")
+	deploy.SetShortDesc("deploy the application")
+	deploy.SetLongDesc("Deploy builds and deploys the application.")
+	target := flags.NewStringFlag("target", "t")
+	target.SetUsage("deployment target")
+	deploy.AddFlag(target)
+	env := flags.NewStringFlag("env")
+	env.SetUsage("deployment environment")
+	deploy.AddPersistentFlag(env)
+
+	rollback := NewCommand("rollback")
+	rollback.SetShortDesc("rollback last deployment")
+	rollback.ArgsAllowed(1)
+	rollback.Do(func(w *Worker) {})
+	rollback.AddExample("deploy rollback v1.0.0", "Rollback to release v1.0.0")
+	deploy.AddSubcommand(rollback)
+	deploy.AddExample("--verbose deploy -t=prod", "Deploy to production")
+	deploy.AddSeeAlso("about-howi")
+	deploy.Do(func(w *Worker) {})
+	app.AddCommand(deploy)
+
+	secret := NewCommand("secret")
+	secret.Hide()
+	secret.Do(func(w *Worker) {})
+	app.AddCommand(secret)
+	return app
+}
+
+func readFile(t *testing.T, name string) string {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestGenerateDocsMarkdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "howi-docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	app := newTestApp(t)
+	if err := app.GenerateDocs(DocsMarkdown, dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app_secret.md")); !os.IsNotExist(err) {
+		t.Error("hidden command should not be documented")
+	}
+	index := readFile(t, filepath.Join(dir, "app.md"))
+	for _, want := range []string{"# app\n\n**Test App**", "[app deploy](app_deploy.md)", "`--help`", "https://example.com/issues"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("app.md should contain %q got:\n%s", want, index)
+		}
+	}
+	deploy := readFile(t, filepath.Join(dir, "app_deploy.md"))
+	for _, want := range []string{"app deploy [flags] [subcommands]", "| `--target` | `-t` | deployment target |",
+		"[app deploy rollback](app_deploy_rollback.md)", "[app](app.md)",
+		"## Examples\n\nDeploy to production\n\n```\n$ app --verbose deploy -t=prod\n```", "* [app about-howi](app_about-howi.md)"} {
+		if !strings.Contains(deploy, want) {
+			t.Errorf("app_deploy.md should contain %q got:\n%s", want, deploy)
+		}
+	}
+	rollback := readFile(t, filepath.Join(dir, "app_deploy_rollback.md"))
+	for _, want := range []string{"app deploy rollback [args]", "[app deploy](app_deploy.md)",
+		"## Inherited flags\n\n| Flag | Aliases | Description |\n| --- | --- | --- |\n| `--env` |  | deployment environment |"} {
+		if !strings.Contains(rollback, want) {
+			t.Errorf("app_deploy_rollback.md should contain %q got:\n%s", want, rollback)
+		}
+	}
+}
+
+func TestGenerateDocsMan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "howi-docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	app := newTestApp(t)
+	app.Project.Title = `The "Test" App\`
+	if err := app.GenerateDocs(DocsMan, dir); err != nil {
+		t.Fatal(err)
+	}
+	page := readFile(t, filepath.Join(dir, "app-deploy.1"))
+	for _, want := range []string{`.TH "APP-DEPLOY" "1"`, ".SH NAME\napp\\-deploy \\- deploy the application",
+		".B \\-\\-target, \\-t", ".SH GLOBAL OPTIONS", ".BR app (1)", ".BR app\\-deploy\\-rollback (1)",
+		".SH EXAMPLES\n.PP\nDeploy to production\n", "$ app \\-\\-verbose deploy \\-t=prod", ".BR app\\-about\\-howi (1)"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("app-deploy.1 should contain %q got:\n%s", want, page)
+		}
+	}
+	if index := readFile(t, filepath.Join(dir, "app.1")); !strings.Contains(index, `"app 0.0.0" "The \(dqTest\(dq App\e Manual"`) {
+		t.Errorf("app.1 title should be escaped for roff got:\n%s", index)
+	}
+}
+
+func TestGenerateDocsRST(t *testing.T) {
+	dir, err := ioutil.TempDir("", "howi-docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	app := newTestApp(t)
+	if err := app.GenerateDocs(DocsRST, dir); err != nil {
+		t.Fatal(err)
+	}
+	page := readFile(t, filepath.Join(dir, "app_deploy.rst"))
+	for _, want := range []string{".. _app_deploy:\n\napp deploy\n==========\n", "``--target, -t``\n  deployment target",
+		":ref:`app deploy rollback <app_deploy_rollback>`", ":ref:`app <app>`",
+		"Examples\n--------\n\nDeploy to production\n\n::\n\n  $ app --verbose deploy -t=prod", ":ref:`app about-howi <app_about-howi>`"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("app_deploy.rst should contain %q got:\n%s", want, page)
+		}
+	}
+}
+
+func TestDocsWriteReference(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestApp(t).Docs().WriteReference(&buf); err != nil {
+		t.Fatal(err)
+	}
+	ref := buf.String()
+	for _, want := range []string{"## app deploy\n", "## app deploy rollback\n", "(#app-deploy-rollback)", "(#app)"} {
+		if !strings.Contains(ref, want) {
+			t.Errorf("reference should contain %q got:\n%s", want, ref)
+		}
+	}
+	if strings.Contains(ref, "secret") {
+		t.Error("hidden command should not be documented")
+	}
+}
+
+func TestGenerateDocsUnknownFormat(t *testing.T) {
+	if err := newTestApp(t).GenerateDocs("html", os.TempDir()); err == nil {
+		t.Error("GenerateDocs should fail with unknown format")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"sort"
+)
+
+// DryRun reports whether application was started with --dry-run flag.
+// Phases and tasks should not make any changes in dry run mode.
+func (w *Worker) DryRun() bool {
+	return w.dryRun
+}
+
+// DryRun reports whether task is executed in dry run mode.
+func (t *Task) DryRun() bool {
+	return t.dryRun
+}
+
+// Step executes side effecting step of the task described by desc. In dry
+// run mode step is logged instead of executed and task is marked as
+// simulated. It returns error returned by fn.
+func (t *Task) Step(desc string, fn func() error) error {
+	if t.dryRun {
+		t.mu.Lock()
+		t.simulated = true
+		t.mu.Unlock()
+		t.log.Notice("dry-run: "+desc, "simulated", true)
+		return nil
+	}
+	t.log.Debug(desc)
+	return fn()
+}
+
+// Simulated reports whether any step of the task was skipped in dry run mode.
+func (t *Task) Simulated() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.simulated
+}
+
+// Status returns string representation of current task status. Successful
+// task with simulated steps has status "simulated".
+func (t *Task) Status() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.simulated && t.status == StatusSuccess {
+		return "simulated"
+	}
+	return statusString(t.status)
+}
+
+// Simulated reports whether phase was executed in dry run mode.
+func (p *Phase) Simulated() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dryRun
+}
+
+// simulatedTasks returns names of finished tasks which had simulated steps.
+func (w *Worker) simulatedTasks() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var names []string
+	for name, t := range w.tasks {
+		if t.Simulated() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantExecuted  bool
+		wantStatus    string
+		wantTaskState string
+	}{
+		{"real", []string{"deploy"}, true, "success", "success"},
+		{"dry-run", []string{"--dry-run", "deploy"}, false, "simulated", "simulated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				executed  bool
+				dryRun    bool
+				doStatus  string
+				taskState string
+				task      *Task
+			)
+			app := newTestApp(t)
+			deploy := app.commands["deploy"]
+			deploy.Do(func(w *Worker) {
+				w.Task("upload", func(tk *Task) {
+					task = tk
+					err := tk.Step("upload release to server", func() error {
+						executed = true
+						return nil
+					})
+					if err != nil {
+						tk.FailErr(err)
+					}
+				})
+			})
+			deploy.AfterSuccess(func(w *Worker) {
+				dryRun = w.DryRun()
+				doStatus = w.phases["do"].Status()
+				taskState = task.Status()
+			})
+			app.commands["deploy"] = deploy
+			code, out := runApp(t, app, tt.args...)
+			if code != 0 {
+				t.Fatalf("exit code want 0 got %d output:\n%s", code, out)
+			}
+			if executed != tt.wantExecuted {
+				t.Errorf("step executed want %t got %t", tt.wantExecuted, executed)
+			}
+			if dryRun == tt.wantExecuted {
+				t.Errorf("AfterSuccess should see dry run %t", !tt.wantExecuted)
+			}
+			if doStatus != tt.wantStatus {
+				t.Errorf("do phase status want %q got %q", tt.wantStatus, doStatus)
+			}
+			if taskState != tt.wantTaskState {
+				t.Errorf("task status want %q got %q", tt.wantTaskState, taskState)
+			}
+			if !tt.wantExecuted {
+				for _, want := range []string{
+					"dry-run: upload release to server phase=do task=upload simulated=true",
+					"dry-run: no changes were made, 1 task(s) simulated: upload",
+				} {
+					if !strings.Contains(out, want) {
+						t.Errorf("output should contain %q got:\n%s", want, out)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"strings"
+
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+// VerifyExamples parses each command example against the command tree the
+// same way as command line is parsed on application start and checks that
+// example invokes the command it is attached to. It also checks that all
+// see also references point to existing commands. It is intended to be used
+// in tests so that documentation can not drift from actual commands and flags.
+// All flags are unset after verification, so it should not be called on
+// application which is started.
+func (cli *Application) VerifyExamples() error {
+	var errs errors.MultiError
+	var verify func(cmds map[string]Command)
+	verify = func(cmds map[string]Command) {
+		for _, cmd := range sortedCommands(cmds) {
+			path := strings.Join(append(append([]string{}, cmd.parents...), cmd.name), " ")
+			for _, ex := range cmd.examples {
+				cli.unsetFlags()
+				args := splitArgs(ex.Cmd)
+				parsed, err := cli.parse(&args)
+				if err != nil {
+					errs.Appendf(FmtErrInvalidExample, ex.Cmd, path, err)
+					continue
+				}
+				if parsed == nil {
+					errs.Appendf(FmtErrExampleOtherCommand, ex.Cmd, path, cli.Project.Name)
+					continue
+				}
+				invoked := strings.Join(append(append([]string{}, parsed.getParents()...), parsed.Name()), " ")
+				if invoked != path {
+					errs.Appendf(FmtErrExampleOtherCommand, ex.Cmd, path, invoked)
+				}
+			}
+			for _, ref := range cmd.seeAlso {
+				if cli.lookupCommand(ref) == nil {
+					errs.Appendf(FmtErrUnknownSeeAlso, ref, path)
+				}
+			}
+			verify(cmd.subCommands)
+		}
+	}
+	verify(cli.commands)
+	cli.unsetFlags()
+	return errs.AsError()
+}
+
+// lookupCommand returns command by its path without application name
+// e.g. "deploy rollback" or nil if there is no such command.
+func (cli *Application) lookupCommand(path string) *Command {
+	cmds := cli.commands
+	var cmd *Command
+	for _, name := range strings.Fields(path) {
+		c, exists := cmds[name]
+		if !exists {
+			return nil
+		}
+		cmd = &c
+		cmds = c.subCommands
+	}
+	return cmd
+}
+
+// unsetFlags unsets global flags and flags of all commands.
+func (cli *Application) unsetFlags() {
+	for _, flag := range cli.flags {
+		flag.Unset()
+	}
+	var unset func(cmds map[string]Command)
+	unset = func(cmds map[string]Command) {
+		for _, cmd := range cmds {
+			for _, flag := range cmd.flags {
+				flag.Unset()
+			}
+			unset(cmd.subCommands)
+		}
+	}
+	unset(cli.commands)
+}
+
+// splitArgs splits command line into arguments. Single and double quotes
+// can be used to group arguments containing spaces, empty arguments are
+// dropped.
+func splitArgs(line string) []string {
+	var (
+		args  []string
+		arg   []rune
+		quote rune
+	)
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg = append(arg, r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ' ' || r == '\t':
+			if len(arg) > 0 {
+				args = append(args, string(arg))
+				arg = arg[:0]
+			}
+		default:
+			arg = append(arg, r)
+		}
+	}
+	if len(arg) > 0 {
+		args = append(args, string(arg))
+	}
+	return args
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/digaverse/howi/lib/update"
+)
+
+func TestVerifyExamples(t *testing.T) {
+	app := newTestApp(t)
+	app.SelfUpdate(&update.Updater{})
+	if err := app.VerifyExamples(); err != nil {
+		t.Fatal(err)
+	}
+	// verification should be repeatable since flags are unset after each example
+	if err := app.VerifyExamples(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyExamplesInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		example string
+		seeAlso string
+		want    string
+	}{
+		{"unknown-flag", "deploy --region=eu", "", `unknown flag "--region=eu"`},
+		{"invalid-option", "generate-docs --format=xml", "", `invalid value "xml"`},
+		{"too-many-args", "deploy rollback v1 v2", "", "too many arguments"},
+		{"other-command", "about-howi", "", `invokes "about-howi"`},
+		{"see-also", "deploy", "deploy upgrade", `see also "deploy upgrade"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t)
+			deploy := app.commands["deploy"]
+			deploy.AddExample(tt.example, "")
+			if tt.seeAlso != "" {
+				deploy.AddSeeAlso(tt.seeAlso)
+			}
+			app.commands["deploy"] = deploy
+			err := app.VerifyExamples()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("VerifyExamples want error containing %q got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"deploy  --target=prod", []string{"deploy", "--target=prod"}},
+		{`deploy --msg="hello world" 'a b'`, []string{"deploy", "--msg=hello world", "a b"}},
+		{`deploy ""`, []string{"deploy"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitArgs(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q) want %q got %q", tt.line, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"fmt"
+)
+
+// Exit codes used by application. Codes 64 - 78 follow sysexits.h
+// conventions and can be used with ExitError.
+const (
+	// ExitOK is exit code of successful execution.
+	ExitOK = 0
+	// ExitFailure is exit code used when command failed without specific code.
+	ExitFailure = 1
+	// ExitUsage command was used incorrectly.
+	ExitUsage = 64
+	// ExitDataErr input data was incorrect.
+	ExitDataErr = 65
+	// ExitNoInput input file did not exist or was not readable.
+	ExitNoInput = 66
+	// ExitNoUser user specified did not exist.
+	ExitNoUser = 67
+	// ExitNoHost host specified did not exist.
+	ExitNoHost = 68
+	// ExitUnavailable service is unavailable.
+	ExitUnavailable = 69
+	// ExitSoftware internal software error.
+	ExitSoftware = 70
+	// ExitOSErr operating system error e.g. can not fork.
+	ExitOSErr = 71
+	// ExitOSFile some system file did not exist or was not readable.
+	ExitOSFile = 72
+	// ExitCantCreate output file can not be created.
+	ExitCantCreate = 73
+	// ExitIOErr error occurred while doing I/O on some file.
+	ExitIOErr = 74
+	// ExitTempFail temporary failure, user is invited to retry.
+	ExitTempFail = 75
+	// ExitProtocol remote system returned something invalid.
+	ExitProtocol = 76
+	// ExitNoPerm insufficient permission to perform the operation.
+	ExitNoPerm = 77
+	// ExitConfig something was found in an unconfigured or misconfigured state.
+	ExitConfig = 78
+	// ExitPanic is exit code used when panic was recovered in any task or phase.
+	ExitPanic = ExitSoftware
+)
+
+// ExitError is error which carries exit code of the application and
+// optional hint displayed to user. Phase failing with ExitError makes
+// application exit with its code.
+type ExitError struct {
+	Code int    // exit code
+	Hint string // optional hint how to resolve the error
+	Err  error  // original error
+}
+
+// NewExitError returns error which makes application exit with given code.
+func NewExitError(code int, err error) *ExitError {
+	return &ExitError{Code: code, Err: err}
+}
+
+// WithHint sets hint displayed to user and returns the error.
+func (e *ExitError) WithHint(hint string) *ExitError {
+	e.Hint = hint
+	return e
+}
+
+// Error implements error.Error method
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns original error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns exit code of the error, codes below 1 are reported as
+// ExitFailure since error can not result successful exit.
+func (e *ExitError) ExitCode() int {
+	if e.Code < 1 {
+		return ExitFailure
+	}
+	return e.Code
+}
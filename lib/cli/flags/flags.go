@@ -159,6 +159,8 @@ func (f *FlagCommon) Verify() error {
 func (f *FlagCommon) Unset() {
 	f.value = vars.Value("")
 	f.isPresent = false
+	f.global = false
+	f.pos = 0
 }
 
 // Present reports whether flag was set in commandline
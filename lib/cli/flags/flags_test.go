@@ -74,3 +74,29 @@ func TestPos(t *testing.T) {
 		t.Errorf("flag.Pos want 0 got %d", flag.Pos())
 	}
 }
+
+func TestOptionFlag(t *testing.T) {
+	flag := NewOptionFlag("output", []string{"text", "json"}, "o")
+	if aliases := flag.GetAliases(); len(aliases) != 2 || aliases[0] != "output" {
+		t.Errorf("GetAliases want [output o] got %v", aliases)
+	}
+	args := []string{"--output=json", "arg"}
+	if ok, err := flag.Parse(&args); !ok || err != nil {
+		t.Errorf("Parse want (true, nil) got (%t, %v)", ok, err)
+	}
+	if flag.Value().String() != "json" {
+		t.Errorf("Value want json got %q", flag.Value())
+	}
+	if len(args) != 1 {
+		t.Errorf("Parse should remove flag from args got %v", args)
+	}
+
+	invalid := NewOptionFlag("output", []string{"text", "json"})
+	args = []string{"--output=xml"}
+	if ok, err := invalid.Parse(&args); ok || err == nil {
+		t.Errorf("Parse want (false, error) got (%t, %v)", ok, err)
+	}
+	if invalid.Present() {
+		t.Error("flag with invalid value should not be present")
+	}
+}
@@ -5,8 +5,10 @@
 package flags
 
 import (
+	"sort"
 	"strings"
 
+	"github.com/digaverse/howi/pkg/errors"
 	"github.com/digaverse/howi/pkg/vars"
 )
 
@@ -19,6 +21,7 @@ func NewOptionFlag(name string, opts []string, a ...string) *OptionFlag {
 	for _, o := range opts {
 		f.opts[o] = true
 	}
+	f.aliases = append(f.aliases, f.name)
 	for _, alias := range a {
 		f.aliases = append(f.aliases, strings.TrimLeft(alias, "-"))
 	}
@@ -32,17 +35,31 @@ type OptionFlag struct {
 	FlagCommon
 }
 
-// Parse the OptionFlag
+// Options returns sorted list of options this flag accepts
+func (f *OptionFlag) Options() []string {
+	var opts []string
+	for o := range f.opts {
+		opts = append(opts, o)
+	}
+	sort.Strings(opts)
+	return opts
+}
+
+// Parse the OptionFlag. It returns error if flag was present with value
+// which is not one of the options.
 func (f *OptionFlag) Parse(args *[]string) (bool, error) {
-	_, err := f.parser(args, func(v *vars.Value) {
+	ok, err := f.parser(args, func(v *vars.Value) {
 		if v.Empty() {
 			*v = vars.Value("")
 		}
 	})
+	if err != nil || !ok {
+		return ok, err
+	}
 	if _, isSet := f.opts[f.value.String()]; !isSet {
 		f.isPresent = false
-		return false, err
+		return false, errors.Newf("invalid value %q for flag %q, must be one of (%s)",
+			f.value, f.name, strings.Join(f.Options(), "|"))
 	}
-
 	return true, nil
 }
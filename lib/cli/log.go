@@ -0,0 +1,50 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"io"
+
+	"github.com/digaverse/howi/pkg/log"
+)
+
+// SetOutputStreams sets output streams of the application. Results, lines
+// and log messages less severe than level are written to stdout. Messages
+// of level and more severe levels as well as prompts are written to stderr.
+// By default errors and more severe messages are written to os.Stderr and
+// everything else to os.Stdout. Use level log.DEBUG to keep stdout free of
+// any log messages.
+func (cli *Application) SetOutputStreams(stdout, stderr io.Writer, level int) {
+	cli.Log.SetOutput(stdout)
+	cli.Log.SetLevelOutput(level, stderr)
+	cli.resultOut = stdout
+	cli.promptOut = stderr
+}
+
+// AddSyslog writes log messages of level and more severe levels also to
+// syslog at addr over network as described in log.DialSyslog. Messages are
+// written in RFC 5424 format with project name as app-name. Connection is
+// closed when application exits.
+func (cli *Application) AddSyslog(network, addr string, level int) error {
+	s, err := log.DialSyslog(network, addr)
+	if err != nil {
+		return err
+	}
+	cli.closeSyslog()
+	cli.Log.AddSink("syslog", log.Sink{W: s, Level: level, Encoder: log.NewSyslogEncoder(cli.Project.Name)})
+	cli.syslog = s
+	return nil
+}
+
+// closeSyslog removes syslog sink added with AddSyslog and closes its
+// connection.
+func (cli *Application) closeSyslog() {
+	if cli.syslog == nil {
+		return
+	}
+	cli.Log.RemoveSink("syslog")
+	cli.syslog.Close()
+	cli.syslog = nil
+}
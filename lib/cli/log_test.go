@@ -0,0 +1,84 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digaverse/howi/pkg/log"
+)
+
+func TestOutputStreams(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	app := newTestApp(t)
+	deploy := app.commands["deploy"]
+	deploy.Do(func(w *Worker) {
+		w.Log.Notice("deploying")
+		w.Log.Line("api 1.2.0")
+		w.Output().Write("release")
+		w.Fail("upload failed")
+	})
+	app.commands["deploy"] = deploy
+	app.exitFn = func(code int) { panic(exitCode(code)) }
+	app.osArgs = []string{"deploy"}
+	app.SetOutputStreams(&stdout, &stderr, log.WARNING)
+	func() {
+		defer func() {
+			if _, ok := recover().(exitCode); !ok {
+				t.Fatal("application did not exit")
+			}
+		}()
+		app.Start()
+	}()
+	for _, want := range []string{"deploying", "api 1.2.0", "release"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout should contain %q got:\n%s", want, stdout.String())
+		}
+	}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if strings.Contains(line, "✗ error") {
+			t.Errorf("stdout should not contain errors got:\n%s", stdout.String())
+		}
+	}
+	if !strings.Contains(stderr.String(), "upload failed") {
+		t.Errorf("stderr should contain error got:\n%s", stderr.String())
+	}
+}
+
+func TestSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	app := newTestApp(t)
+	if err := app.AddSyslog("udp", conn.LocalAddr().String(), log.WARNING); err != nil {
+		t.Fatal(err)
+	}
+	deploy := app.commands["deploy"]
+	deploy.Do(func(w *Worker) {
+		w.Log.Notice("deploying")
+		w.Log.Warning("slow upload")
+	})
+	app.commands["deploy"] = deploy
+	runApp(t, app, "deploy")
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := string(buf[:n]); !strings.HasPrefix(msg, "<12>1 ") || !strings.Contains(msg, " app ") ||
+		!strings.HasSuffix(msg, " slow upload") {
+		t.Errorf("unexpected syslog message %q", msg)
+	}
+	if app.syslog != nil || len(app.Log.Sinks()) != 0 {
+		t.Errorf("syslog should be closed and removed on exit got sinks %v", app.Log.Sinks())
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+// Output formats supported by --output flag. Template format is used as
+// --output=template=<go template> e.g. --output='template={{.Name}}'.
+const (
+	OutputTable    = "table"
+	OutputText     = "text" // alias of table
+	OutputJSON     = "json"
+	OutputYAML     = "yaml"
+	OutputCSV      = "csv"
+	OutputTemplate = "template"
+)
+
+// tableMinColumn is width to which columns of the table can be shrunk.
+const tableMinColumn = 8
+
+// Output writes results of the command in format selected with --output
+// flag. Values are first encoded as JSON, so json struct tags are respected
+// in all formats except template which receives values as they are.
+// Slice of structs or maps is written as table with row per element.
+type Output struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format string
+	tmpl   *template.Template
+	width  int
+}
+
+// newOutput returns output writing to w in format described by spec.
+// Width is used to fit tables into terminal, 0 disables the limit.
+func newOutput(w io.Writer, spec string, width int) (*Output, error) {
+	o := &Output{w: w, format: OutputTable, width: width}
+	if spec == "" {
+		return o, nil
+	}
+	format := spec
+	if i := strings.IndexByte(spec, '='); i >= 0 {
+		format = spec[:i]
+	}
+	switch format {
+	case OutputTable, OutputText, OutputJSON, OutputYAML, OutputCSV:
+		if format != spec {
+			return nil, errors.Newf(FmtErrUnknownOutputFormat, spec)
+		}
+		if format == OutputText {
+			format = OutputTable
+		}
+	case OutputTemplate:
+		if format == spec {
+			return nil, errors.Newf(FmtErrUnknownOutputFormat, spec)
+		}
+		tmpl, err := template.New("output").Parse(spec[len(format)+1:])
+		if err != nil {
+			return nil, errors.Newf(FmtErrInvalidOutputTemplate, err)
+		}
+		o.tmpl = tmpl
+	default:
+		return nil, errors.Newf(FmtErrUnknownOutputFormat, spec)
+	}
+	o.format = format
+	return o, nil
+}
+
+// Format returns name of the output format.
+func (o *Output) Format() string {
+	return o.format
+}
+
+// Write writes v in selected output format.
+func (o *Output) Write(v interface{}) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch o.format {
+	case OutputJSON:
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	case OutputYAML:
+		data, err = marshalYAML(v)
+	case OutputTemplate:
+		var buf bytes.Buffer
+		err = o.tmpl.Execute(&buf, v)
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte{'\n'}) {
+			buf.WriteByte('\n')
+		}
+		data = buf.Bytes()
+	default:
+		var header []string
+		var rows [][]string
+		header, rows, err = outputRows(v)
+		if err != nil {
+			break
+		}
+		if o.format == OutputCSV {
+			data, err = encodeCSV(header, rows)
+		} else {
+			data = encodeTable(header, rows, o.width)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, err = o.w.Write(data)
+	return err
+}
+
+// writeText writes preformatted text as it is.
+func (o *Output) writeText(text string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, err := io.WriteString(o.w, text)
+	return err
+}
+
+// outputRows converts v to table rows. Header is nil when value is scalar
+// or list of scalars.
+func outputRows(v interface{}) (header []string, rows [][]string, err error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := yamlDecode(dec)
+	if err != nil {
+		return nil, nil, err
+	}
+	list, ok := node.([]interface{})
+	if !ok {
+		list = []interface{}{node}
+	}
+	columns := make(map[string]int)
+	for _, item := range list {
+		m, ok := item.(*yamlMap)
+		if !ok {
+			continue
+		}
+		for _, key := range m.keys {
+			if _, exists := columns[key]; !exists {
+				columns[key] = len(header)
+				header = append(header, key)
+			}
+		}
+	}
+	for _, item := range list {
+		m, ok := item.(*yamlMap)
+		if !ok {
+			rows = append(rows, []string{outputCell(item)})
+			continue
+		}
+		row := make([]string, len(header))
+		for i, key := range m.keys {
+			row[columns[key]] = outputCell(m.vals[i])
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+// outputCell returns string representation of table cell, nested values
+// are written as JSON.
+func outputCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case *yamlMap, []interface{}:
+		var buf bytes.Buffer
+		outputJSON(&buf, val)
+		return buf.String()
+	}
+	return yamlScalar(v)
+}
+
+// outputJSON writes decoded node as compact JSON preserving order of keys.
+func outputJSON(buf *bytes.Buffer, node interface{}) {
+	switch n := node.(type) {
+	case *yamlMap:
+		buf.WriteByte('{')
+		for i, key := range n.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(key)
+			buf.Write(k)
+			buf.WriteByte(':')
+			outputJSON(buf, n.vals[i])
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, val := range n {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			outputJSON(buf, val)
+		}
+		buf.WriteByte(']')
+	default:
+		b, _ := json.Marshal(n)
+		buf.Write(b)
+	}
+}
+
+func encodeCSV(header []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if header != nil {
+		w.Write(header)
+	}
+	w.WriteAll(rows)
+	return buf.Bytes(), w.Error()
+}
+
+// encodeTable writes rows as aligned columns. When table is wider than
+// width then widest columns are shrunk and their cells truncated. Width 0
+// does not limit the table.
+func encodeTable(header []string, rows [][]string, width int) []byte {
+	var upper []string
+	for _, h := range header {
+		upper = append(upper, strings.ToUpper(h))
+	}
+	all := rows
+	if upper != nil {
+		all = append([][]string{upper}, rows...)
+	}
+	var widths []int
+	for _, row := range all {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if l := utf8.RuneCountInString(cell); l > widths[i] {
+				widths[i] = l
+			}
+		}
+	}
+	const sep = 2
+	total := sep * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for width > 0 && total > width {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= tableMinColumn {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+	var buf bytes.Buffer
+	for _, row := range all {
+		var line strings.Builder
+		for i, cell := range row {
+			cell = truncate(cell, widths[i])
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+sep))
+			}
+		}
+		buf.WriteString(strings.TrimRight(line.String(), " "))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// truncate shortens s to width runes marking truncation with "...".
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	if width <= 3 {
+		return string(r[:width])
+	}
+	return string(r[:width-3]) + "..."
+}
+
+// SetResultOutput sets writer where results written with Worker.Output
+// are written, defaults to os.Stdout.
+func (cli *Application) SetResultOutput(w io.Writer) {
+	cli.resultOut = w
+}
+
+// Output returns writer for results of the command. Results should be
+// written with Output instead of the Log so that they can be parsed when
+// structured output format is selected with --output flag.
+func (w *Worker) Output() *Output {
+	return w.output
+}
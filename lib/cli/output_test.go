@@ -0,0 +1,128 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type testRelease struct {
+	Name    string            `json:"name"`
+	Version string            `json:"version"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+func TestOutputFormats(t *testing.T) {
+	releases := []testRelease{
+		{Name: "api", Version: "1.2.0"},
+		{Name: "web, ui", Version: "0.9.1", Labels: map[string]string{"env": "prod"}},
+	}
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "NAME     VERSION  LABELS\napi      1.2.0\nweb, ui  0.9.1    {\"env\":\"prod\"}\n"},
+		{"text", "NAME     VERSION  LABELS\napi      1.2.0\nweb, ui  0.9.1    {\"env\":\"prod\"}\n"},
+		{"csv", "name,version,labels\napi,1.2.0,\n\"web, ui\",0.9.1,\"{\"\"env\"\":\"\"prod\"\"}\"\n"},
+		{"json", "[\n  {\n    \"name\": \"api\",\n    \"version\": \"1.2.0\"\n  },\n" +
+			"  {\n    \"name\": \"web, ui\",\n    \"version\": \"0.9.1\",\n    \"labels\": {\n      \"env\": \"prod\"\n    }\n  }\n]\n"},
+		{"yaml", "- name: api\n  version: \"1.2.0\"\n- name: web, ui\n  version: \"0.9.1\"\n  labels:\n    env: prod\n"},
+		{"template={{range .}}{{.Name}}@{{.Version}} {{end}}", "api@1.2.0 web, ui@0.9.1 \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			var buf bytes.Buffer
+			o, err := newOutput(&buf, tt.spec, 80)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Write(releases); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("want:\n%q\ngot:\n%q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestOutputInvalidSpec(t *testing.T) {
+	for _, spec := range []string{"xml", "json=1", "template", "template={{.Name"} {
+		if _, err := newOutput(&bytes.Buffer{}, spec, 80); err == nil {
+			t.Errorf("spec %q should be invalid", spec)
+		}
+	}
+}
+
+func TestOutputTableWidth(t *testing.T) {
+	var buf bytes.Buffer
+	o, _ := newOutput(&buf, OutputTable, 30)
+	o.Write([]map[string]string{
+		{"name": "api", "description": "service providing public api of the application"},
+	})
+	want := "DESCRIPTION               NAME\nservice providing pub...  api\n"
+	if buf.String() != want {
+		t.Errorf("want:\n%q\ngot:\n%q", want, buf.String())
+	}
+	buf.Reset()
+	o, _ = newOutput(&buf, OutputTable, 0)
+	o.Write([]map[string]string{
+		{"name": "api", "description": "service providing public api of the application"},
+	})
+	want = "DESCRIPTION                                      NAME\nservice providing public api of the application  api\n"
+	if buf.String() != want {
+		t.Errorf("width 0 should not limit table want:\n%q\ngot:\n%q", want, buf.String())
+	}
+	buf.Reset()
+	o.Write("single value")
+	if buf.String() != "single value\n" {
+		t.Errorf("scalar should be written as is got %q", buf.String())
+	}
+}
+
+func TestOutputFlag(t *testing.T) {
+	var result bytes.Buffer
+	app := newTestApp(t)
+	app.SetResultOutput(&result)
+	deploy := app.commands["deploy"]
+	deploy.Do(func(w *Worker) {
+		w.Log.Notice("deploying")
+		if err := w.Output().Write(testRelease{Name: "api", Version: "1.2.0"}); err != nil {
+			w.FailErr(err)
+		}
+	})
+	app.commands["deploy"] = deploy
+	code, out := runApp(t, app, "--output=json", "deploy")
+	if code != 0 {
+		t.Fatalf("exit code want 0 got %d output:\n%s", code, out)
+	}
+	if want := "{\n  \"name\": \"api\",\n  \"version\": \"1.2.0\"\n}\n"; result.String() != want {
+		t.Errorf("result want %q got %q", want, result.String())
+	}
+	if !strings.Contains(out, "deploying") {
+		t.Errorf("logs should not be written to results got:\n%s", out)
+	}
+
+	app = newTestApp(t)
+	code, out = runApp(t, app, "--output=xml", "deploy")
+	if code != 2 || !strings.Contains(out, `unknown output format "xml"`) {
+		t.Errorf("invalid format should fail with code 2 got %d output:\n%s", code, out)
+	}
+
+	for _, args := range [][]string{{"about-howi", "--output=text"}, {"about-howi", "--contributors"}} {
+		result.Reset()
+		app = newTestApp(t)
+		app.SetResultOutput(&result)
+		code, out = runApp(t, app, args...)
+		if code != 0 || !strings.Contains(result.String(), "Project Contributors") {
+			t.Errorf("%v should write about text to results got %d result:\n%s", args, code, result.String())
+		}
+		if strings.Contains(out, "Project Contributors") {
+			t.Errorf("%v should not write about text to logs got:\n%s", args, out)
+		}
+	}
+}
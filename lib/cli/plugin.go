@@ -0,0 +1,106 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+// Plugin extends Application with reusable functionality. Plugin is
+// installed with Application.Use and it can register flags, commands and
+// help topics in Register. Plugin may additionally implement PhaseWrapper,
+// StartupHook and ShutdownHook interfaces.
+type Plugin interface {
+	// Name returns unique name of the plugin.
+	Name() string
+	// Register is called once when plugin is installed.
+	Register(app *Application) error
+}
+
+// PhaseWrapper is implemented by plugins which wrap execution of every
+// phase function (Before, Do, AfterFailure, AfterSuccess and AfterAlways)
+// e.g. for timing, tracing or authorization. Name of the phase is available
+// with w.Phase().Name(). Wrapper must call next to execute the phase.
+type PhaseWrapper interface {
+	WrapPhase(next func(w *Worker)) func(w *Worker)
+}
+
+// StartupHook is implemented by plugins which need to run after flags have
+// been parsed and before the first phase is executed. Returned error
+// prevents command from executing.
+type StartupHook interface {
+	Startup(w *Worker) error
+}
+
+// ShutdownHook is implemented by plugins which need to run after all phases
+// have been executed and before application exits. Shutdown hooks are
+// called in reverse order of installation.
+type ShutdownHook interface {
+	Shutdown(w *Worker)
+}
+
+// Use installs plugins to application. Plugins are registered in given order
+// and registration error prevents application to start.
+func (cli *Application) Use(plugins ...Plugin) {
+	for _, p := range plugins {
+		if cli.installed(p.Name()) {
+			cli.errs.Add(errors.Newf(FmtErrPluginInstalled, p.Name()))
+			continue
+		}
+		cli.Log.Debugf("CLI:Use - installing plugin %q", p.Name())
+		if err := p.Register(cli); err != nil {
+			cli.errs.Add(errors.Newf(FmtErrPluginRegister, p.Name(), err))
+			continue
+		}
+		cli.plugins = append(cli.plugins, p)
+	}
+}
+
+func (cli *Application) installed(name string) bool {
+	for _, p := range cli.plugins {
+		if p.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// phaseWrappers returns wrappers of installed plugins where first installed
+// plugin is the outermost wrapper.
+func (cli *Application) phaseWrappers() []func(next func(w *Worker)) func(w *Worker) {
+	var wrappers []func(next func(w *Worker)) func(w *Worker)
+	for _, p := range cli.plugins {
+		if pw, ok := p.(PhaseWrapper); ok {
+			wrappers = append(wrappers, pw.WrapPhase)
+		}
+	}
+	return wrappers
+}
+
+// startup calls startup hooks of installed plugins and stops on first error.
+// Plugins which started are recorded so that only those are shut down.
+func (cli *Application) startup(w *Worker) error {
+	for _, p := range cli.plugins {
+		if sh, ok := p.(StartupHook); ok {
+			cli.Log.Debugf("CLI:startup - plugin %q", p.Name())
+			if err := sh.Startup(w); err != nil {
+				return errors.Newf(FmtErrPluginStartup, p.Name(), err)
+			}
+		}
+		cli.running++
+	}
+	return nil
+}
+
+// shutdown calls shutdown hooks of started plugins in reverse order.
+func (cli *Application) shutdown(w *Worker) {
+	for i := cli.running - 1; i >= 0; i-- {
+		if sh, ok := cli.plugins[i].(ShutdownHook); ok {
+			cli.Log.Debugf("CLI:shutdown - plugin %q", cli.plugins[i].Name())
+			sh.Shutdown(w)
+		}
+	}
+	cli.running = 0
+}
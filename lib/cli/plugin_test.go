@@ -0,0 +1,119 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/digaverse/howi/lib/cli/flags"
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+type testPlugin struct {
+	name       string
+	log        *[]string
+	startupErr error
+}
+
+func (p *testPlugin) Name() string {
+	return p.name
+}
+
+func (p *testPlugin) Register(app *Application) error {
+	trace := flags.NewBoolFlag(p.name + "-trace")
+	trace.SetUsage("enable tracing")
+	app.AddFlag(trace)
+	cmd := NewCommand(p.name + "-status")
+	cmd.Do(func(w *Worker) {
+		*p.log = append(*p.log, p.name+":status")
+	})
+	app.AddCommand(cmd)
+	return nil
+}
+
+func (p *testPlugin) WrapPhase(next func(w *Worker)) func(w *Worker) {
+	return func(w *Worker) {
+		*p.log = append(*p.log, p.name+":>"+w.Phase().Name())
+		next(w)
+		*p.log = append(*p.log, p.name+":<"+w.Phase().Name())
+	}
+}
+
+func (p *testPlugin) Startup(w *Worker) error {
+	*p.log = append(*p.log, p.name+":startup")
+	return p.startupErr
+}
+
+func (p *testPlugin) Shutdown(w *Worker) {
+	*p.log = append(*p.log, p.name+":shutdown")
+}
+
+type failingPlugin struct{}
+
+func (failingPlugin) Name() string                    { return "failing" }
+func (failingPlugin) Register(app *Application) error { return errors.New("no config") }
+
+func TestPlugins(t *testing.T) {
+	var got []string
+	app := newTestApp(t)
+	app.Use(&testPlugin{name: "a", log: &got}, &testPlugin{name: "b", log: &got})
+	status := app.commands["b-status"]
+	status.Do(func(w *Worker) {
+		got = append(got, "b:status")
+		if flag, err := w.Flag("a-trace"); err != nil || !flag.Present() {
+			t.Errorf("flag a-trace should be parsed got %v", err)
+		}
+	})
+	app.commands["b-status"] = status
+	code, out := runApp(t, app, "--a-trace", "b-status")
+	if code != 0 {
+		t.Fatalf("exit code want 0 got %d output:\n%s", code, out)
+	}
+	want := []string{
+		"a:startup", "b:startup",
+		"a:>do", "b:>do", "b:status", "b:<do", "a:<do",
+		"b:shutdown", "a:shutdown",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("plugins executed in wrong order\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestPluginStartupError(t *testing.T) {
+	var got []string
+	app := newTestApp(t)
+	app.Use(&testPlugin{name: "a", log: &got},
+		&testPlugin{name: "b", log: &got, startupErr: errors.New("unauthorized")},
+		&testPlugin{name: "c", log: &got})
+	code, out := runApp(t, app, "a-status")
+	if code != 1 {
+		t.Errorf("exit code want 1 got %d", code)
+	}
+	if !strings.Contains(out, `plugin "b" failed to start: unauthorized`) {
+		t.Errorf("output should contain startup error got:\n%s", out)
+	}
+	// only plugins which started are shut down
+	if want := []string{"a:startup", "b:startup", "a:shutdown"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q got %q", want, got)
+	}
+}
+
+func TestPluginRegisterErrors(t *testing.T) {
+	var got []string
+	app := newTestApp(t)
+	app.Use(&testPlugin{name: "a", log: &got}, &testPlugin{name: "a", log: &got}, failingPlugin{})
+	var msgs []string
+	for _, err := range app.errs {
+		msgs = append(msgs, err.Error())
+	}
+	err := strings.Join(msgs, "\n")
+	for _, want := range []string{`plugin "a" is already installed`, `plugin "failing" failed to register: no config`} {
+		if !strings.Contains(err, want) {
+			t.Errorf("errors should contain %q got %q", want, err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"strconv"
+
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+// FailurePolicy defines how phase reacts to failures of its tasks. Value of
+// the policy is number of task failures after which phase is failed and
+// canceled: context of the phase is canceled so that running tasks can stop
+// and new tasks are not started. Failures below the threshold are still
+// reported by Worker.TaskErrors. With FailContinue phase is failed on first
+// failure but it is never canceled.
+type FailurePolicy int
+
+const (
+	// FailContinue runs all tasks of the phase and reports all failures.
+	FailContinue FailurePolicy = 0
+	// FailFast cancels phase on first failure. It is default policy.
+	FailFast FailurePolicy = 1
+)
+
+// FailAfter returns policy which cancels phase after n failures.
+// Values of n less than 1 are same as FailFast.
+func FailAfter(n int) FailurePolicy {
+	if n < 1 {
+		return FailFast
+	}
+	return FailurePolicy(n)
+}
+
+// ParseFailurePolicy parses policy from string "fail-fast", "continue" or
+// number of failures after which phase is canceled.
+func ParseFailurePolicy(s string) (FailurePolicy, error) {
+	switch s {
+	case "fail-fast":
+		return FailFast, nil
+	case "continue":
+		return FailContinue, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return FailFast, errors.Newf(FmtErrInvalidFailurePolicy, s)
+	}
+	return FailAfter(n), nil
+}
+
+// String returns string representation of the policy.
+func (p FailurePolicy) String() string {
+	switch p {
+	case FailContinue:
+		return "continue"
+	case FailFast:
+		return "fail-fast"
+	}
+	return strconv.Itoa(int(p))
+}
+
+// fails reports whether phase with given number of task failures has failed.
+func (p FailurePolicy) fails(failures int) bool {
+	return p == FailContinue || failures >= int(p)
+}
+
+// cancels reports whether phase with given number of failures
+// should be canceled.
+func (p FailurePolicy) cancels(failures int) bool {
+	return p != FailContinue && failures >= int(p)
+}
+
+// SetFailurePolicy sets default failure policy of all phases. Policy can be
+// overridden with global flag --on-failure and within phase function with
+// Worker.SetFailurePolicy.
+func (cli *Application) SetFailurePolicy(p FailurePolicy) {
+	cli.policy = p
+}
+
+// SetFailurePolicy sets failure policy of current phase. It should be called
+// before any task is scheduled.
+func (w *Worker) SetFailurePolicy(p FailurePolicy) {
+	w.Phase().setPolicy(p)
+}
@@ -0,0 +1,150 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseFailurePolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    FailurePolicy
+		wantErr bool
+	}{
+		{"fail-fast", FailFast, false},
+		{"continue", FailContinue, false},
+		{"1", FailFast, false},
+		{"3", FailAfter(3), false},
+		{"0", FailFast, true},
+		{"-2", FailFast, true},
+		{"sometimes", FailFast, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFailurePolicy(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFailurePolicy(%q) error = %v, wantErr %t", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseFailurePolicy(%q) want %s got %s", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	w := newTestWorker()
+	w.call(func(w *Worker) {
+		w.Task("slow", func(task *Task) {
+			<-task.Context().Done()
+			task.FailErr(task.Context().Err())
+		})
+		w.Task("fail", func(task *Task) {
+			task.Fail("failed")
+		})
+		w.Wait()
+		w.Task("skipped", func(task *Task) {})
+	})
+	w.phasewait()
+	if _, err := w.WaitTaskPayloadFrom("skipped"); err == nil {
+		t.Error("task scheduled after phase was canceled should be skipped")
+	}
+	if n := len(w.TaskErrors()); n != 2 {
+		t.Errorf("want 2 task errors got %d", n)
+	}
+}
+
+func TestFailAfter(t *testing.T) {
+	w := newTestWorker()
+	w.SetFailurePolicy(FailAfter(3))
+	var canceled []bool
+	w.call(func(w *Worker) {
+		for i := 0; i < 4; i++ {
+			w.Task(fmt.Sprintf("task-%d", i), func(task *Task) {
+				task.Fail("failed")
+			})
+			w.Wait()
+			canceled = append(canceled, w.Context().Err() != nil)
+		}
+	})
+	w.phasewait()
+	if want := []bool{false, false, true, true}; fmt.Sprint(canceled) != fmt.Sprint(want) {
+		t.Errorf("phase canceled want %v got %v", want, canceled)
+	}
+	if n := len(w.TaskErrors()); n != 3 {
+		t.Errorf("want 3 task errors got %d", n)
+	}
+	if !w.Failed() {
+		t.Error("phase should be failed")
+	}
+}
+
+func TestFailurePolicyFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		fails    int
+		wantCode int
+		wantErrs int
+	}{
+		{"default", []string{"deploy"}, 5, ExitFailure, 1},
+		{"continue", []string{"--on-failure=continue", "deploy"}, 5, ExitFailure, 5},
+		{"threshold", []string{"--on-failure=2", "deploy"}, 5, ExitFailure, 2},
+		{"below threshold", []string{"--on-failure=3", "deploy"}, 2, ExitOK, 2},
+		{"invalid", []string{"--on-failure=never", "deploy"}, 5, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var terrs []*TaskError
+			app := newTestApp(t)
+			deploy := app.commands["deploy"]
+			deploy.Do(func(w *Worker) {
+				for i := 0; i < tt.fails; i++ {
+					w.Task(fmt.Sprintf("task-%d", i), func(task *Task) {
+						task.Fail("failed")
+					})
+					w.Wait()
+				}
+			})
+			deploy.AfterAlways(func(w *Worker) {
+				terrs = w.TaskErrors()
+			})
+			app.commands["deploy"] = deploy
+			code, out := runApp(t, app, tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code want %d got %d output:\n%s", tt.wantCode, code, out)
+			}
+			if len(terrs) != tt.wantErrs {
+				t.Errorf("want %d task errors got %d", tt.wantErrs, len(terrs))
+			}
+			if tt.wantCode == 2 && !strings.Contains(out, `invalid failure policy "never"`) {
+				t.Errorf("output should contain invalid policy error got:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestPanicBelowThreshold(t *testing.T) {
+	var failed bool
+	app := newTestApp(t)
+	deploy := app.commands["deploy"]
+	deploy.Do(func(w *Worker) {
+		w.Task("explode", func(task *Task) {
+			panic("boom")
+		})
+	})
+	deploy.AfterFailure(func(w *Worker) {
+		failed = true
+	})
+	app.commands["deploy"] = deploy
+	code, out := runApp(t, app, "--on-failure=3", "deploy")
+	if code != ExitPanic {
+		t.Errorf("exit code want %d got %d output:\n%s", ExitPanic, code, out)
+	}
+	if !failed {
+		t.Error("recovered panic should fail the phase regardless of failure policy")
+	}
+}
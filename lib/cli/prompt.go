@@ -0,0 +1,296 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/digaverse/howi/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Prompt asks input from the user. When prompting is disabled with --no-input
+// flag or input is not a terminal, prompts return default values or error
+// when there is no default. Flag --yes answers yes to all confirmations.
+// Prompt can be used from multiple go routines, prompts are asked one by one.
+type Prompt struct {
+	mu          sync.Mutex
+	in          *bufio.Reader
+	out         io.Writer
+	fd          int  // file descriptor of terminal input or -1
+	interactive bool // prompting is enabled
+	assumeYes   bool // answer yes to confirmations
+}
+
+// newPrompt returns prompt reading from in and writing questions to out.
+// Input which is not *os.File e.g. scripted input in tests is considered
+// interactive.
+func newPrompt(in io.Reader, out io.Writer) *Prompt {
+	p := &Prompt{
+		in:          bufio.NewReader(in),
+		out:         out,
+		fd:          -1,
+		interactive: true,
+	}
+	if f, ok := in.(*os.File); ok {
+		p.interactive = terminal.IsTerminal(int(f.Fd()))
+		if p.interactive {
+			p.fd = int(f.Fd())
+		}
+	}
+	return p
+}
+
+// noPrompt returns non-interactive prompt which answers with defaults. It
+// is used by worker until application sets prompt configured with flags.
+func noPrompt() *Prompt {
+	return &Prompt{in: bufio.NewReader(strings.NewReader("")), out: ioutil.Discard, fd: -1}
+}
+
+// Interactive reports whether prompt asks input from the user.
+func (p *Prompt) Interactive() bool {
+	return p.interactive
+}
+
+// Text asks text input. Empty answer returns def. If validate is not nil
+// then question is asked again until answer is valid.
+func (p *Prompt) Text(msg string, def string, validate func(string) error) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.interactive {
+		if def == "" {
+			return "", errors.Newf(FmtErrPromptNoInput, msg)
+		}
+		return def, validateAnswer(def, validate)
+	}
+	question := msg + ": "
+	if def != "" {
+		question = fmt.Sprintf("%s [%s]: ", msg, def)
+	}
+	for {
+		answer, err := p.ask(question)
+		if err != nil {
+			return "", errors.Newf(FmtErrPromptRead, msg, err)
+		}
+		if answer == "" {
+			answer = def
+		}
+		if err := validateAnswer(answer, validate); err != nil {
+			fmt.Fprintln(p.out, err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// Password asks input without echoing it to the terminal.
+func (p *Prompt) Password(msg string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.interactive {
+		return "", errors.Newf(FmtErrPromptNoInput, msg)
+	}
+	if p.fd < 0 {
+		answer, err := p.ask(msg + ": ")
+		if err != nil {
+			return "", errors.Newf(FmtErrPromptRead, msg, err)
+		}
+		return answer, nil
+	}
+	fmt.Fprint(p.out, msg+": ")
+	b, err := terminal.ReadPassword(p.fd)
+	fmt.Fprintln(p.out)
+	if err != nil {
+		return "", errors.Newf(FmtErrPromptRead, msg, err)
+	}
+	return string(b), nil
+}
+
+// Confirm asks yes or no question. Empty answer returns def.
+func (p *Prompt) Confirm(msg string, def bool) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.assumeYes {
+		return true, nil
+	}
+	if !p.interactive {
+		return def, nil
+	}
+	question := msg + " [y/N]: "
+	if def {
+		question = msg + " [Y/n]: "
+	}
+	for {
+		answer, err := p.ask(question)
+		if err != nil {
+			return false, errors.Newf(FmtErrPromptRead, msg, err)
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// Select asks to choose one of the options and returns index of the chosen
+// option. Empty answer returns def, use -1 when there is no default.
+func (p *Prompt) Select(msg string, options []string, def int) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.interactive {
+		if def < 0 || def >= len(options) {
+			return -1, errors.Newf(FmtErrPromptNoInput, msg)
+		}
+		return def, nil
+	}
+	p.printOptions(options)
+	question := msg + ": "
+	if def >= 0 && def < len(options) {
+		question = fmt.Sprintf("%s [%d]: ", msg, def+1)
+	}
+	for {
+		answer, err := p.ask(question)
+		if err != nil {
+			return -1, errors.Newf(FmtErrPromptRead, msg, err)
+		}
+		if answer == "" && def >= 0 && def < len(options) {
+			return def, nil
+		}
+		choice, err := parseChoice(answer, len(options))
+		if err != nil {
+			fmt.Fprintln(p.out, err)
+			continue
+		}
+		return choice, nil
+	}
+}
+
+// MultiSelect asks to choose any of the options separated by comma or space
+// and returns indexes of the chosen options. Empty answer returns defs,
+// when defs is nil then at least one option must be chosen.
+func (p *Prompt) MultiSelect(msg string, options []string, defs []int) ([]int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.interactive {
+		if defs == nil {
+			return nil, errors.Newf(FmtErrPromptNoInput, msg)
+		}
+		return defs, nil
+	}
+	p.printOptions(options)
+	question := msg + ": "
+	if defs != nil {
+		var labels []string
+		for _, d := range defs {
+			labels = append(labels, strconv.Itoa(d+1))
+		}
+		question = fmt.Sprintf("%s [%s]: ", msg, strings.Join(labels, ","))
+	}
+asking:
+	for {
+		answer, err := p.ask(question)
+		if err != nil {
+			return nil, errors.Newf(FmtErrPromptRead, msg, err)
+		}
+		if answer == "" && defs != nil {
+			return defs, nil
+		}
+		var choices []int
+		chosen := make(map[int]bool)
+		for _, field := range strings.FieldsFunc(answer, func(r rune) bool {
+			return r == ',' || r == ' '
+		}) {
+			choice, err := parseChoice(field, len(options))
+			if err != nil {
+				fmt.Fprintln(p.out, err)
+				continue asking
+			}
+			if !chosen[choice] {
+				chosen[choice] = true
+				choices = append(choices, choice)
+			}
+		}
+		if len(choices) == 0 {
+			continue
+		}
+		return choices, nil
+	}
+}
+
+// ask writes question and reads trimmed answer. Answer without trailing
+// new line at the end of input is accepted.
+func (p *Prompt) ask(question string) (string, error) {
+	fmt.Fprint(p.out, question)
+	answer, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+func (p *Prompt) printOptions(options []string) {
+	for i, o := range options {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, o)
+	}
+}
+
+func validateAnswer(answer string, validate func(string) error) error {
+	if validate == nil {
+		return nil
+	}
+	return validate(answer)
+}
+
+// parseChoice parses 1-based option number and returns index of the option.
+func parseChoice(answer string, options int) (int, error) {
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > options {
+		return -1, errors.Newf(FmtErrPromptInvalidChoice, answer, options)
+	}
+	return n - 1, nil
+}
+
+// SetPromptIO sets input and output used by prompts, defaults are os.Stdin
+// and os.Stderr. Input which is not a file e.g. strings.Reader is considered
+// interactive, which makes it possible to script answers in tests.
+func (cli *Application) SetPromptIO(in io.Reader, out io.Writer) {
+	cli.promptIn, cli.promptOut = in, out
+}
+
+// newPrompt returns prompt configured with global flags --yes and --no-input.
+func (cli *Application) newPrompt() *Prompt {
+	p := newPrompt(cli.promptIn, cli.promptOut)
+	if cli.flag("no-input").Present() {
+		p.interactive = false
+	}
+	p.assumeYes = cli.flag("yes").Present()
+	return p
+}
+
+// Prompt returns prompt for asking input from the user.
+func (w *Worker) Prompt() *Prompt {
+	return w.prompt
+}
+
+// AskForConfirmation returns user choice. It returns false when reading the
+// answer fails or prompting is disabled, unless --yes flag was used.
+func (w *Worker) AskForConfirmation(s string) bool {
+	ok, err := w.prompt.Confirm(s, false)
+	if err != nil {
+		w.Log.Error(err)
+	}
+	return ok
+}
@@ -0,0 +1,187 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+func TestPromptText(t *testing.T) {
+	notEmpty := func(s string) error {
+		if s == "" {
+			return errors.New("value required")
+		}
+		return nil
+	}
+	tests := []struct {
+		name     string
+		input    string
+		def      string
+		validate func(string) error
+		want     string
+		wantErr  bool
+	}{
+		{"answer", "prod\n", "", nil, "prod", false},
+		{"default", "\n", "dev", nil, "dev", false},
+		{"retry", "\n\nstage\n", "", notEmpty, "stage", false},
+		{"no-newline", "prod", "", nil, "prod", false},
+		{"eof", "", "", notEmpty, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := newPrompt(strings.NewReader(tt.input), &out)
+			got, err := p.Text("environment", tt.def, tt.validate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("want %q got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPromptConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		def   bool
+		want  bool
+	}{
+		{"y\n", false, true},
+		{"YES\n", false, true},
+		{"no\n", true, false},
+		{"\n", true, true},
+		{"maybe\nn\n", true, false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		p := newPrompt(strings.NewReader(tt.input), &out)
+		got, err := p.Confirm("continue", tt.def)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("input %q want %t got %t", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestPromptSelect(t *testing.T) {
+	options := []string{"dev", "stage", "prod"}
+	var out bytes.Buffer
+	p := newPrompt(strings.NewReader("4\n3\n\n"), &out)
+	got, err := p.Select("environment", options, -1)
+	if err != nil || got != 2 {
+		t.Errorf("want 2 got %d (%v)", got, err)
+	}
+	if !strings.Contains(out.String(), "  3) prod\n") ||
+		!strings.Contains(out.String(), `invalid choice "4"`) {
+		t.Errorf("unexpected prompt output:\n%s", out.String())
+	}
+	got, err = p.Select("environment", options, 1)
+	if err != nil || got != 1 {
+		t.Errorf("want default 1 got %d (%v)", got, err)
+	}
+
+	p = newPrompt(strings.NewReader("3, 1 3\n\n"), &out)
+	choices, err := p.MultiSelect("environments", options, nil)
+	if err != nil || !reflect.DeepEqual(choices, []int{2, 0}) {
+		t.Errorf("want [2 0] got %v (%v)", choices, err)
+	}
+	choices, err = p.MultiSelect("environments", options, []int{1})
+	if err != nil || !reflect.DeepEqual(choices, []int{1}) {
+		t.Errorf("want default [1] got %v (%v)", choices, err)
+	}
+}
+
+func TestPromptPassword(t *testing.T) {
+	var out bytes.Buffer
+	p := newPrompt(strings.NewReader("s3cret\n"), &out)
+	got, err := p.Password("password")
+	if err != nil || got != "s3cret" {
+		t.Errorf("want s3cret got %q (%v)", got, err)
+	}
+}
+
+func TestPromptNonInteractive(t *testing.T) {
+	var out bytes.Buffer
+	p := newPrompt(strings.NewReader("ignored\n"), &out)
+	p.interactive = false
+	if got, err := p.Text("name", "howi", nil); err != nil || got != "howi" {
+		t.Errorf("text want default got %q (%v)", got, err)
+	}
+	if _, err := p.Text("name", "", nil); err == nil {
+		t.Error("text without default should fail")
+	}
+	if _, err := p.Password("password"); err == nil {
+		t.Error("password should fail")
+	}
+	if got, err := p.Confirm("continue", true); err != nil || !got {
+		t.Errorf("confirm want default got %t (%v)", got, err)
+	}
+	if _, err := p.Select("env", []string{"dev"}, -1); err == nil {
+		t.Error("select without default should fail")
+	}
+	if _, err := p.MultiSelect("env", []string{"dev"}, nil); err == nil {
+		t.Error("multi select without default should fail")
+	}
+	if out.Len() > 0 {
+		t.Errorf("non interactive prompt should not write output got %q", out.String())
+	}
+}
+
+func TestWorkerDefaultPrompt(t *testing.T) {
+	w := newTestWorker()
+	if w.Prompt().Interactive() {
+		t.Error("default prompt of worker should not be interactive")
+	}
+	if w.AskForConfirmation("continue") {
+		t.Error("confirmation should default to no")
+	}
+}
+
+func TestPromptFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		input   string
+		want    bool
+		wantErr bool
+	}{
+		{"scripted", []string{"deploy"}, "y\nv1.0.0\n", true, false},
+		{"yes", []string{"--yes", "deploy"}, "v1.0.0\n", true, false},
+		{"no-input", []string{"--no-input", "deploy"}, "y\n", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				confirmed bool
+				textErr   error
+				out       bytes.Buffer
+			)
+			app := newTestApp(t)
+			app.SetPromptIO(strings.NewReader(tt.input), &out)
+			deploy := app.commands["deploy"]
+			deploy.Do(func(w *Worker) {
+				confirmed = w.AskForConfirmation("deploy to production")
+				_, textErr = w.Prompt().Text("release", "", nil)
+			})
+			app.commands["deploy"] = deploy
+			runApp(t, app, tt.args...)
+			if confirmed != tt.want {
+				t.Errorf("confirmation want %t got %t", tt.want, confirmed)
+			}
+			if (textErr != nil) != tt.wantErr {
+				t.Errorf("text prompt unexpected error %v", textErr)
+			}
+		})
+	}
+}
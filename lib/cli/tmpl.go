@@ -19,6 +19,10 @@ type TmplParser struct {
 	tmpl   string
 	buffer bytes.Buffer
 	t      *template.Template
+	colors bool
+	width  int
+	cmdCol int
+	flgCol int
 }
 
 // SetTemplate sets template to be parsed
@@ -26,10 +30,26 @@ func (t *TmplParser) SetTemplate(tmpl string) {
 	t.tmpl = tmpl
 }
 
+// SetOutput configures template functions for the output. When colors are
+// disabled then no ANSI escape sequences are emitted and text is wrapped to
+// given width.
+func (t *TmplParser) SetOutput(colors bool, width int) {
+	t.colors = colors
+	t.width = width
+}
+
+// SetColumns sets width of command and flag name columns used by
+// funcCmdName and funcFlagName, zero value restores the default.
+func (t *TmplParser) SetColumns(cmd, flag int) {
+	t.cmdCol = cmd
+	t.flgCol = flag
+}
+
 // ParseTmpl parses template for cli application
 // arg name is template name, arg info is data passed to template
 // and elapsed is time duration used by specific type of templates and can usually set to "0"
 func (t *TmplParser) ParseTmpl(name string, info interface{}, elapsed time.Duration) error {
+	t.buffer.Reset()
 	t.t = template.New(name)
 	t.t.Funcs(template.FuncMap{
 		"funcTextBold":    t.textBold,
@@ -38,8 +58,16 @@ func (t *TmplParser) ParseTmpl(name string, info interface{}, elapsed time.Durat
 		"funcFlagName":    t.flagName,
 		"funcDate":        t.dateOnly,
 		"funcElapsed":     func() string { return elapsed.String() },
+		"funcFlagLabel":   flagLabel,
+		"funcCmdDesc":     t.cmdDesc,
+		"funcFlagDesc":    t.flagDesc,
+		"funcWrap":        t.wrap,
+		"funcIndent":      t.indent,
 	})
-	tmpl := template.Must(t.t.Parse(t.tmpl))
+	tmpl, err := t.t.Parse(t.tmpl)
+	if err != nil {
+		return err
+	}
 	return tmpl.Execute(&t.buffer, info)
 }
 
@@ -55,22 +83,54 @@ func (t *TmplParser) cmdCategory(s string) string {
 	return strings.ToUpper(s)
 }
 
+func (t *TmplParser) cmdColumn() int {
+	if t.cmdCol > 0 {
+		return t.cmdCol
+	}
+	return 20
+}
+
+func (t *TmplParser) flagColumn() int {
+	if t.flgCol > 0 {
+		return t.flgCol
+	}
+	return 25
+}
+
 func (t *TmplParser) cmdName(s string) string {
 	if s == "" {
 		return s
 	}
-	return fmt.Sprintf("\033[1m %-20s\033[0m", s)
+	col := t.cmdColumn()
+	if len(s) > col {
+		return t.textBold(s) + "\n" + t.indent(helpRowIndent+col+2)
+	}
+	return t.textBold(fmt.Sprintf("%-*s", col, s)) + "  "
 }
 
 func (t *TmplParser) flagName(s string) string {
 	if s == "" {
 		return s
 	}
-	return fmt.Sprintf("%-25s", s)
+	col := t.flagColumn()
+	if len(s) > col {
+		return s + "\n" + t.indent(helpRowIndent+col+2)
+	}
+	return fmt.Sprintf("%-*s  ", col, s)
+}
+
+// cmdDesc wraps command description following the funcCmdName column.
+func (t *TmplParser) cmdDesc(s string) string {
+	return t.wrap(helpRowIndent+t.cmdColumn()+2, s)
+}
+
+// flagDesc wraps flag usage following the funcFlagName column.
+func (t *TmplParser) flagDesc(s string) string {
+	return t.wrap(helpRowIndent+t.flagColumn()+2, s)
 }
 
 func (t *TmplParser) textBold(s string) string {
-	if s == "" {
+	if s == "" || !t.colors {
 		return s
 	}
 	return fmt.Sprintf("\033[1m%s\033[0m", s)
@@ -81,6 +141,39 @@ func (t *TmplParser) dateOnly(ts time.Time) string {
 	return fmt.Sprintf("%.2d-%.2d-%d", d, m, y)
 }
 
+func (t *TmplParser) indent(n int) string {
+	return strings.Repeat(" ", n)
+}
+
+// wrap wraps text to the output width assuming that text starts at column
+// indent. Following lines are indented to same column.
+func (t *TmplParser) wrap(indent int, s string) string {
+	width := t.width
+	if width <= 0 {
+		width = 80
+	}
+	limit := width - indent
+	if limit < 20 {
+		limit = 20
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len(line)+1+len(word) > limit {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"+t.indent(indent))
+}
+
 // Header can be shown right after application is loaded.
 type Header struct {
 	TmplParser
@@ -93,13 +186,16 @@ func (h *Header) Defaults() {
 # {{ .Title }}{{ if .Copyright.Since }}
 #  Copyright © {{ .Copyright.Since }} {{ .Copyright.By }}. All rights reserved.{{end}}
 # {{if .Version}}
-#   Version:    {{ .Version }}{{end}}{{if .BuildDate}}
-#   Build date: {{ .BuildDate | funcDate }}{{end}}
+#   Version:    {{ .Version }}{{end}}{{if not .BuildDate.IsZero}}
+#   Build date: {{ .BuildDate | funcDate }}{{end}}{{if .Commit}}
+#   Commit:     {{ .Commit }}{{if .Dirty}} (dirty){{end}}{{end}}{{if .GoVersion}}
+#   Go version: {{ .GoVersion }}{{end}}
 ################################################################################`)
 }
 
 // Print application header
 func (h *Header) Print(log *log.Logger, project interface{}, elapsed time.Duration) {
+	h.SetOutput(log.ColorsEnabled(), log.TermWidth())
 	err := h.ParseTmpl("header-tmpl", project, elapsed)
 	if err != nil {
 		log.Fatal(err)
@@ -122,6 +218,7 @@ func (f *Footer) Defaults() {
 
 // Print application footer
 func (f *Footer) Print(log *log.Logger, project interface{}, elapsed time.Duration) {
+	f.SetOutput(log.ColorsEnabled(), log.TermWidth())
 	err := f.ParseTmpl("footer-tmpl", project, elapsed)
 	if err != nil {
 		log.Fatal(err)
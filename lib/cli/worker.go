@@ -5,11 +5,12 @@
 package cli
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"fmt"
-	"os"
 	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/digaverse/howi/lib/cli/flags"
@@ -20,66 +21,127 @@ import (
 	"github.com/digaverse/howi/pkg/vars"
 )
 
+// phaseNames lists phases in order of execution.
+var phaseNames = []string{"before", "do", "after-failure", "after-success", "after-always"}
+
 // Worker is instance shared between command phases
 type Worker struct {
-	mu           sync.Mutex // ensures atomic writes; protects worker fields
-	wg           sync.WaitGroup
-	started      time.Time
-	phase        string
-	phases       map[string]*Phase
-	taskPayloads map[string]chan []byte
-	args         []vars.Value
-	flags        map[int]flags.Interface // global flags
-	flagAliases  map[string]int          // global flag aliases
-	Log          *log.Logger
-	Config       WorkerConfig
-	Project      *project.Project
+	mu          sync.Mutex // ensures atomic writes; protects worker fields
+	wg          sync.WaitGroup
+	started     time.Time
+	phase       string
+	phases      map[string]*Phase
+	tasks       map[string]*Task
+	args        []vars.Value
+	flags       map[int]flags.Interface // global flags
+	flagAliases map[string]int          // global flag aliases
+	Log         *log.Logger
+	Config      WorkerConfig
+	Project     *project.Project
+	wrappers    []func(next func(w *Worker)) func(w *Worker) // phase wrappers of plugins
+	panicked    bool                                         // panic was recovered in task or phase
+	err         error                                        // first error which failed the worker
+	policy      FailurePolicy                                // default failure policy of phases
+	prompt      *Prompt
+	dryRun      bool // side effects of tasks are logged instead of executed
+	output      *Output
 }
 
 // NewWorker constructs new worker
 func newWorker(prj *project.Project, args []vars.Value, logger *log.Logger) *Worker {
 	w := &Worker{
-		started:      time.Now(),
-		phases:       make(map[string]*Phase),
-		taskPayloads: make(map[string]chan []byte),
-		args:         args,
-		Log:          logger,
+		started: time.Now(),
+		phases:  make(map[string]*Phase),
+		tasks:   make(map[string]*Task),
+		args:    args,
+		Log:     logger,
 		Config: WorkerConfig{
 			ShowHeader: true,
 			ShowFooter: true,
 		},
 		Project: prj,
+		policy:  FailFast,
+		prompt:  noPrompt(),
+	}
+	for _, name := range phaseNames {
+		w.phases[name] = newPhase(name)
 	}
-	w.phases["before"] = newPhase("before")
-	w.phases["do"] = newPhase("do")
-	w.phases["after-failure"] = newPhase("after-failure")
-	w.phases["after-success"] = newPhase("after-success")
-	w.phases["after-always"] = newPhase("after-always")
 	return w
 }
 
 // Fail marks phase as failed
 func (w *Worker) Fail(msg string) {
-	w.Phase().msg = msg
-	w.Phase().status = StatusFailed
+	w.FailErr(errors.New(msg))
 }
 
 // Failf marks phase as failed
 // Arguments are handled in the manner of fmt.Srintf.
 func (w *Worker) Failf(format string, v ...interface{}) {
-	w.Phase().msg = fmt.Sprintf(format, v...)
-	w.Phase().status = StatusFailed
+	w.FailErr(errors.Newf(format, v...))
+}
+
+// FailErr marks phase as failed with error. When error is or wraps
+// *ExitError then application exits with its exit code.
+func (w *Worker) FailErr(err error) {
+	w.fail(w.Phase(), "", err)
+}
+
+// Err returns error which failed the worker first or nil. It can be used in
+// AfterFailure to inspect original error with errors.As.
+func (w *Worker) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// fail marks phase as failed, task is name of the task which failed
+// or empty if failure did not occur in task.
+func (w *Worker) fail(phase *Phase, task string, err error) {
+	phase.fail(task, err)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// exitCode returns exit code of the application based on worker state.
+// Failures in After phases do not change exit code of succeeded command.
+func (w *Worker) exitCode(failed bool) int {
+	if w.Panicked() {
+		return ExitPanic
+	}
+	err := w.Err()
+	if !failed {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return ExitFailure
+}
+
+// hint returns hint of the ExitError which failed the worker if any.
+func (w *Worker) hint() string {
+	var exitErr *ExitError
+	if errors.As(w.Err(), &exitErr) {
+		return exitErr.Hint
+	}
+	return ""
 }
 
 // Failed returns true if tasks in current phase have failed
 func (w *Worker) Failed() bool {
-	return w.Phase().status == StatusFailed
+	return w.Phase().getStatus() == StatusFailed
 }
 
-// Task for worker
+// Task for worker. Task function is executed in it's own go routine, so
+// tasks can be scheduled concurrently from phase functions and other tasks.
 func (w *Worker) Task(name string, wt func(task *Task)) {
-	if w.Phase().status == StatusFailed {
-		w.Log.Warningf("skipping task %q since previous task failed.", name)
+	phase := w.Phase()
+	if phase.canceled() {
+		w.Logger().Warning("skipping task since phase was canceled after failure", "task", name)
 		return
 	}
 	// Check task name and exit on failure
@@ -87,28 +149,34 @@ func (w *Worker) Task(name string, wt func(task *Task)) {
 		w.Log.Fatalf("task name %q is invalid - must match following regex %v",
 			name, namespace.NamespaceMustCompile)
 	}
-	if _, exists := w.taskPayloads[name]; exists {
+	t := newTask(phase.ctx, name)
+	t.log = w.Log.With("phase", phase.Name(), "task", name)
+	t.dryRun = w.dryRun
+	w.mu.Lock()
+	if _, exists := w.tasks[name]; exists {
+		w.mu.Unlock()
 		w.Log.Fatalf("task name %q is already in use", name)
+		return
 	}
-	w.mu.Lock()
-	w.taskPayloads[name] = make(chan []byte)
+	w.tasks[name] = t
 	w.mu.Unlock()
 
 	w.wg.Add(1)
-	t := &Task{name: name}
 	go func() {
+		defer w.wg.Done()
+		// finish publishes the payload, so it must be called last
+		defer t.finish()
 		defer func() {
-			w.wg.Done()
-			w.taskPayloads[name] <- t.payload
-			close(w.taskPayloads[name])
+			if err := w.recoverPanic(phase, name, recover()); err != nil {
+				t.fail(err)
+			}
 		}()
 		t.start()
 		wt(t)
 		// Mark phase as failed if task failed without AllowFailure
-		if t.status == StatusFailed {
-			w.Fail(t.msg)
+		if t.Failed() {
+			w.fail(phase, name, t.Err())
 		}
-		t.finish()
 	}()
 }
 
@@ -125,7 +193,7 @@ func (w *Worker) Flag(alias string) (flags.Interface, error) {
 			return w.flags[id], nil
 		}
 	}
-	return nil, errors.Newf(FmtErrUnknownFlag, alias)
+	return nil, errors.Newf(FmtErrUnknownWorkerFlag, alias)
 }
 
 // Wait for all previous tasks to complete before scheduling next task
@@ -137,55 +205,148 @@ func (w *Worker) Wait() {
 
 // Phase information
 func (w *Worker) Phase() *Phase {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	return w.phases[w.phase]
 }
 
-// AskForConfirmation returns user choice
-func (w *Worker) AskForConfirmation(s string) bool {
-	reader := bufio.NewReader(os.Stdin)
-
-	for {
-		w.Log.ColoredLinef("%s [y/n]: ", s)
+// Logger returns logger which attaches name of the current phase
+// to every message.
+func (w *Worker) Logger() *log.Entry {
+	return w.Log.With("phase", w.Phase().Name())
+}
 
-		r, err := reader.ReadString('\n')
-		if err != nil {
-			w.Log.Fatal(err)
-		}
+// Context returns context of current phase. Context is canceled when
+// phase is canceled by its failure policy or when phase has finished.
+func (w *Worker) Context() context.Context {
+	return w.Phase().ctx
+}
 
-		r = strings.ToLower(strings.TrimSpace(r))
+// startPhase starts current phase with default failure policy.
+func (w *Worker) startPhase() {
+	w.Phase().start(w.policy, w.dryRun)
+}
 
-		if r == "y" || r == "yes" {
-			return true
-		} else if r == "n" || r == "no" {
-			return false
-		}
-	}
+// setPhase sets current phase and returns it.
+func (w *Worker) setPhase(name string) *Phase {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.phase = name
+	return w.phases[w.phase]
 }
 
 // WaitTaskPayloadFrom enables you to wait payload from specific tasks.
-// Only one task can consume the payload, Having more consumers expecting same payload
-// then first consumer should pass it as it's payload.
+// It blocks until task has finished and returns payload set by the task.
+// Any number of tasks and phases can wait for the payload of the same task.
+// Task must be scheduled before its payload can be waited.
 func (w *Worker) WaitTaskPayloadFrom(name string) ([]byte, error) {
 	w.mu.Lock()
-	_, isChan := w.taskPayloads[name]
+	t, exists := w.tasks[name]
 	w.mu.Unlock()
-	if isChan {
-		payload := <-w.taskPayloads[name]
-		return payload, nil
+	if !exists {
+		return nil, errors.Newf("no such task registered %q", name)
 	}
-
-	return nil, errors.Newf("no such task registered %q", name)
+	<-t.done
+	return t.Payload(), nil
 }
 
 // wait for the phase to return
 func (w *Worker) phasewait() {
+	phase := w.Phase()
 	w.Log.Debugf("phase: %s status: %s, started: %s",
-		w.Phase().Name(), w.Phase().Status(), w.Phase().started.String())
+		phase.Name(), phase.Status(), phase.Started().String())
 
 	w.wg.Wait()
-	w.Phase().finish()
-	w.Log.Debugf("phase: %s status: %s, elapsed: %s", w.Phase().Name(),
-		w.Phase().Status(), w.Phase().Elapsed())
+	phase.finish()
+	w.Log.Debugf("phase: %s status: %s, elapsed: %s", phase.Name(),
+		phase.Status(), phase.Elapsed())
+}
+
+// call executes phase function wrapped with phase wrappers of plugins
+// where first wrapper is the outermost. Panic in phase function is recovered
+// and marks phase as failed.
+func (w *Worker) call(fn func(w *Worker)) {
+	phase := w.Phase()
+	defer func() {
+		w.recoverPanic(phase, "", recover())
+	}()
+	for i := len(w.wrappers) - 1; i >= 0; i-- {
+		fn = w.wrappers[i](fn)
+	}
+	fn(w)
+}
+
+// recoverPanic converts recovered value r into error with stack trace of
+// the panic and marks phase as failed. Task is name of the task where panic
+// occurred or empty when phase function panicked. It returns nil if there
+// was no panic.
+func (w *Worker) recoverPanic(phase *Phase, task string, r interface{}) error {
+	if r == nil {
+		return nil
+	}
+	source := "phase " + phase.Name()
+	if task != "" {
+		source = "task " + task
+	}
+	err := errors.WithStackTrace(fmt.Sprintf("%s panic: %v", source, r))
+	w.mu.Lock()
+	w.panicked = true
+	w.mu.Unlock()
+	w.fail(phase, task, err)
+	phase.failPanic(err)
+
+	// frames of recovering function and runtime are noise in stack trace
+	// of the panic, so trace starts from function which panicked.
+	frames := err.GetStackTrace()
+	for i, frame := range frames {
+		if frame.Package() == "runtime" && frame.Func() == "gopanic" {
+			frames = frames[i+1:]
+			break
+		}
+	}
+	var trace []string
+	for _, frame := range frames {
+		if frame.Package() == "runtime" {
+			continue
+		}
+		trace = append(trace, "    "+frame.String())
+	}
+	w.Log.Debugf("%s\n%s", err, strings.Join(trace, "\n"))
+	return err
+}
+
+// Panicked reports whether panic was recovered in any task or phase.
+func (w *Worker) Panicked() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.panicked
+}
+
+// TaskErrors returns failures of all tasks in order of phases and in order
+// the tasks failed within the phase. It can be used in AfterFailure to decide
+// what has to be rolled back.
+func (w *Worker) TaskErrors() []*TaskError {
+	var terrs []*TaskError
+	for _, name := range phaseNames {
+		terrs = append(terrs, w.phases[name].TaskErrors()...)
+	}
+	return terrs
+}
+
+// taskErrorsSummary returns aligned table rows of failed tasks.
+func (w *Worker) taskErrorsSummary() []string {
+	terrs := w.TaskErrors()
+	if len(terrs) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PHASE\tTASK\tERROR")
+	for _, terr := range terrs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", terr.Phase, terr.Task, terr.Err)
+	}
+	tw.Flush()
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
 }
 
 func (w *Worker) attachFlag(f flags.Interface) {
@@ -209,20 +370,31 @@ type WorkerConfig struct {
 }
 
 func newPhase(name string) *Phase {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Phase{
 		name:   name,
 		status: StatusPending,
+		ctx:    ctx,
+		cancel: cancel,
 	}
 }
 
-// Phase tracks execution of specific phase
+// Phase tracks execution of specific phase. It is safe to use phase
+// from multiple go routines.
 type Phase struct {
+	mu         sync.Mutex // protects fields below
 	started    time.Time
 	finished   time.Time
 	status     uint
 	msg        string
+	err        error
+	errs       errors.MultiError // all failures of the phase
 	name       string
 	totalTasks int
+	policy     FailurePolicy
+	dryRun     bool
+	ctx        context.Context
+	cancel     context.CancelFunc
 }
 
 // Name returns name of the phase
@@ -230,56 +402,200 @@ func (p *Phase) Name() string {
 	return p.name
 }
 
+// Err returns error which failed the phase if it is known e.g. recovered
+// panic containing stack trace.
+func (p *Phase) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Errors returns all failures of the phase in order they occurred.
+// Failures of tasks are of type *TaskError.
+func (p *Phase) Errors() errors.MultiError {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append(errors.MultiError{}, p.errs...)
+}
+
+// TaskErrors returns failures of tasks in order they occurred.
+func (p *Phase) TaskErrors() []*TaskError {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var terrs []*TaskError
+	for _, err := range p.errs {
+		if terr, ok := err.(*TaskError); ok {
+			terrs = append(terrs, terr)
+		}
+	}
+	return terrs
+}
+
+// Started returns time when phase was started.
+func (p *Phase) Started() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.started
+}
+
 // Elapsed returns how long phase has been running
 func (p *Phase) Elapsed() string {
-	if p.status == StatusFailed {
-		p.finish()
-	}
-	if p.status == StatusRunning {
-		return time.Now().Sub(p.started).String()
-	}
-	return p.finished.Sub(p.started).String()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return elapsed(p.status, p.started, p.finished).String()
 }
 
-// Status returns string representation of current phase status
-func (p *Phase) Status() (status string) {
-	switch p.status {
-	case StatusPending:
-		status = "pending"
-	case StatusRunning:
-		status = "running"
-	case StatusSuccess:
-		status = "success"
-	case StatusSkipped:
-		status = "skipped"
-	default: // PhaseFailed
-		status = "failed"
+// Status returns string representation of current phase status.
+// Successful phase executed in dry run mode has status "simulated".
+func (p *Phase) Status() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dryRun && p.status == StatusSuccess {
+		return "simulated"
 	}
-	return
+	return statusString(p.status)
+}
+
+func (p *Phase) getStatus() uint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+func (p *Phase) message() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.msg
+}
+
+// Policy returns failure policy of the phase.
+func (p *Phase) Policy() FailurePolicy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.policy
+}
+
+func (p *Phase) setPolicy(policy FailurePolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policy = policy
 }
 
-func (p *Phase) start() {
+func (p *Phase) canceled() bool {
+	return p.ctx.Err() != nil
+}
+
+func (p *Phase) start(policy FailurePolicy, dryRun bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.started = time.Now()
 	p.status = StatusRunning
+	p.policy = policy
+	p.dryRun = dryRun
+}
+
+func (p *Phase) skip() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = StatusSkipped
+}
+
+// fail records failure of the phase. Message and error of the phase are
+// set by the first failure, all failures are collected to phase errors.
+func (p *Phase) fail(task string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if task != "" {
+		p.errs.Add(&TaskError{Phase: p.name, Task: task, Err: err})
+	} else {
+		p.errs.Add(err)
+	}
+	if p.policy.cancels(p.errs.Len()) {
+		p.cancel()
+	}
+	// failures of tasks below threshold of the policy do not fail the phase
+	if task != "" && !p.policy.fails(p.errs.Len()) {
+		return
+	}
+	p.markFailed(err)
+}
+
+// failPanic marks phase as failed by recovered panic regardless of failure
+// policy. Panic must be already recorded with fail.
+func (p *Phase) failPanic(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.markFailed(err)
+}
+
+// markFailed sets status of the phase to failed unless it has failed
+// already. Caller must hold p.mu.
+func (p *Phase) markFailed(err error) {
+	if p.status == StatusFailed {
+		return
+	}
+	p.msg = err.Error()
+	p.err = err
+	if !p.started.IsZero() && p.finished.IsZero() {
+		p.finished = time.Now()
+	}
+	p.status = StatusFailed
 }
 
 func (p *Phase) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.finished = time.Now()
 	if p.status == StatusRunning {
 		p.status = StatusSuccess
 	}
+	p.cancel()
+}
+
+// TaskError is failure of the task.
+type TaskError struct {
+	Phase string // name of the phase task was attached to
+	Task  string // name of the task
+	Err   error
+}
+
+// Error returns error message prefixed with task name.
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %s: %s", e.Task, e.Err)
+}
+
+// Unwrap returns underlying error of the task.
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+func newTask(ctx context.Context, name string) *Task {
+	return &Task{
+		name:   name,
+		status: StatusPending,
+		done:   make(chan struct{}),
+		ctx:    ctx,
+	}
 }
 
 // Task is single task which will be executed in it's own go routine
-// within the execution phase it was attached to.
+// within the execution phase it was attached to. It is safe to use task
+// from multiple go routines.
 type Task struct {
+	mu           sync.Mutex // protects fields below
 	started      time.Time
 	finished     time.Time
 	name         string
 	payload      []byte
 	status       uint
 	msg          string
+	err          error
 	allowFailure bool
+	done         chan struct{}   // closed when task has finished
+	ctx          context.Context // context of the phase
+	log          *log.Entry
+	dryRun       bool
+	simulated    bool // side effect was skipped in dry run mode
 }
 
 // Name returns the name of the task
@@ -287,48 +603,130 @@ func (t *Task) Name() string {
 	return t.name
 }
 
+// Context returns context of the phase task is attached to. Long running
+// tasks should stop when context is done, which happens when phase is
+// canceled by its failure policy.
+func (t *Task) Context() context.Context {
+	return t.ctx
+}
+
+// Log returns logger which attaches names of the phase and the task
+// to every message.
+func (t *Task) Log() *log.Entry {
+	return t.log
+}
+
 // SetPayload sets payload which can be retrieved by next tasks or phases.
 func (t *Task) SetPayload(p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.payload = p
 }
 
+// Payload returns payload set by the task.
+func (t *Task) Payload() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.payload
+}
+
 // AllowFailure marks this task to be allowed to fail
 func (t *Task) AllowFailure() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.allowFailure = true
 }
 
 // Fail marks tasks as failed it updates status only if AllowFailure was not called
 func (t *Task) Fail(msg string) {
-	t.msg = msg
+	t.FailErr(errors.New(msg))
+}
+
+// FailErr marks tasks as failed with error, it updates status only if
+// AllowFailure was not called.
+func (t *Task) FailErr(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.msg = err.Error()
+	t.err = err
 	if !t.allowFailure {
 		t.status = StatusFailed
-	} else {
-		t.finish()
 	}
 }
 
 // Failed returns true if task has failed
 func (t *Task) Failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.status == StatusFailed
 }
 
-// Elapsed returns how long phase has been running
+// Err returns error which failed the task or error of the task which was
+// allowed to fail.
+func (t *Task) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// Elapsed returns how long task has been running
 func (t *Task) Elapsed() string {
-	t.finish()
-	if t.status == StatusSuccess {
-		return time.Now().Sub(t.started).String()
-	}
-	return t.finished.Sub(t.started).String()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return elapsed(t.status, t.started, t.finished).String()
 }
 
 func (t *Task) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.started = time.Now()
 	t.status = StatusRunning
 }
 
+// fail marks task as failed regardless of AllowFailure.
+func (t *Task) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.msg = err.Error()
+	t.err = err
+	t.status = StatusFailed
+}
+
+// finish marks task as finished and releases goroutines waiting for its payload.
 func (t *Task) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.finished = time.Now()
 	if t.status == StatusRunning {
 		t.status = StatusSuccess
 	}
+	close(t.done)
+}
+
+// elapsed returns duration between start and finish or time since start
+// while still running.
+func elapsed(status uint, started, finished time.Time) time.Duration {
+	if started.IsZero() {
+		return 0
+	}
+	if status == StatusRunning || finished.IsZero() {
+		return time.Since(started)
+	}
+	return finished.Sub(started)
+}
+
+// statusString returns string representation of phase or task status.
+func statusString(status uint) string {
+	switch status {
+	case StatusPending:
+		return "pending"
+	case StatusRunning:
+		return "running"
+	case StatusSuccess:
+		return "success"
+	case StatusSkipped:
+		return "skipped"
+	default: // StatusFailed
+		return "failed"
+	}
 }
@@ -0,0 +1,122 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/digaverse/howi/pkg/log"
+	"github.com/digaverse/howi/pkg/project"
+)
+
+func newTestWorker() *Worker {
+	w := newWorker(&project.Project{Name: "app"}, nil, log.New(&bytes.Buffer{}, log.NOTICE))
+	w.setPhase("do")
+	w.startPhase()
+	return w
+}
+
+// TestWorkerTasksConcurrent should be run with -race flag.
+func TestWorkerTasksConcurrent(t *testing.T) {
+	const producers = 2000
+	w := newTestWorker()
+	var consumed int64
+	w.call(func(w *Worker) {
+		for i := 0; i < producers; i++ {
+			name := fmt.Sprintf("producer-%d", i)
+			payload := []byte(strconv.Itoa(i))
+			w.Task(name, func(task *Task) {
+				task.SetPayload(payload)
+			})
+		}
+		// several consumers of the same payload and payloads nobody consumes
+		for i := 0; i < producers; i += 2 {
+			for j := 0; j < 3; j++ {
+				from := fmt.Sprintf("producer-%d", i)
+				w.Task(fmt.Sprintf("consumer-%d-%d", i, j), func(task *Task) {
+					payload, err := w.WaitTaskPayloadFrom(from)
+					if err != nil {
+						task.FailErr(err)
+						return
+					}
+					if want := from[len("producer-"):]; string(payload) != want {
+						task.Fail(fmt.Sprintf("payload from %s want %q got %q", from, want, payload))
+						return
+					}
+					atomic.AddInt64(&consumed, 1)
+					_ = w.Phase().Status()
+					_ = w.Phase().Elapsed()
+					_ = task.Elapsed()
+				})
+			}
+		}
+	})
+	w.phasewait()
+
+	if w.Failed() {
+		t.Fatal(w.Phase().message())
+	}
+	if want := int64(producers / 2 * 3); consumed != want {
+		t.Errorf("consumed payloads want %d got %d", want, consumed)
+	}
+	if status := w.Phase().Status(); status != "success" {
+		t.Errorf("phase status want success got %s", status)
+	}
+	payload, err := w.WaitTaskPayloadFrom("producer-1")
+	if err != nil || string(payload) != "1" {
+		t.Errorf("payload after phase want %q got %q (%v)", "1", payload, err)
+	}
+	if _, err := w.WaitTaskPayloadFrom("unknown"); err == nil {
+		t.Error("expected error when waiting payload from unknown task")
+	}
+}
+
+func TestWorkerTasksConcurrentFailures(t *testing.T) {
+	const tasks = 3000
+	w := newTestWorker()
+	w.call(func(w *Worker) {
+		for i := 0; i < tasks; i++ {
+			i := i
+			w.Task(fmt.Sprintf("task-%d", i), func(task *Task) {
+				switch {
+				case i%100 == 99:
+					panic("boom")
+				case i%10 == 9:
+					task.Fail("failed")
+				case i%2 == 1:
+					task.AllowFailure()
+					task.Fail("allowed")
+					if task.Failed() {
+						t.Error("task allowed to fail should not be marked as failed")
+					}
+				}
+				_ = w.Failed()
+			})
+		}
+	})
+	w.phasewait()
+
+	if !w.Failed() {
+		t.Fatal("phase should be failed")
+	}
+	if !w.Panicked() {
+		t.Error("worker should report recovered panic")
+	}
+	if w.Err() == nil || w.Phase().Err() == nil {
+		t.Error("worker and phase should have error")
+	}
+	// waiting payload of finished task must not block, tasks scheduled
+	// after the failure are skipped and not registered
+	for i := 0; i < tasks; i++ {
+		w.WaitTaskPayloadFrom(fmt.Sprintf("task-%d", i))
+	}
+	if status := w.Phase().Status(); status != "failed" {
+		t.Errorf("phase status want failed got %s", status)
+	}
+}
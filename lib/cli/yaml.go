@@ -0,0 +1,173 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// yamlMap preserves order of the keys as they appear in JSON document.
+type yamlMap struct {
+	keys []string
+	vals []interface{}
+}
+
+// marshalYAML encodes v as YAML document. Value is first encoded as JSON
+// so json struct tags and json.Marshaler implementations are respected
+// and only JSON compatible values can be encoded.
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := yamlDecode(dec)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	yamlEncode(&buf, node, 0)
+	return buf.Bytes(), nil
+}
+
+func yamlDecode(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := &yamlMap{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			val, err := yamlDecode(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.keys = append(m.keys, key.(string))
+			m.vals = append(m.vals, val)
+		}
+		_, err = dec.Token()
+		return m, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			val, err := yamlDecode(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, val)
+		}
+		_, err = dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+func yamlEncode(w io.Writer, node interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch n := node.(type) {
+	case *yamlMap:
+		if len(n.keys) == 0 {
+			io.WriteString(w, pad+"{}\n")
+			return
+		}
+		for i, key := range n.keys {
+			io.WriteString(w, pad+yamlScalar(key)+":")
+			yamlEncodeChild(w, n.vals[i], indent)
+		}
+	case []interface{}:
+		if len(n) == 0 {
+			io.WriteString(w, pad+"[]\n")
+			return
+		}
+		for _, val := range n {
+			// write first key of the map on the same line with item indicator
+			if m, ok := val.(*yamlMap); ok && len(m.keys) > 0 {
+				var buf bytes.Buffer
+				yamlEncode(&buf, m, indent+1)
+				io.WriteString(w, pad+"- "+strings.TrimPrefix(buf.String(), pad+"  "))
+				continue
+			}
+			io.WriteString(w, pad+"-")
+			yamlEncodeChild(w, val, indent)
+		}
+	default:
+		io.WriteString(w, pad+yamlScalar(n)+"\n")
+	}
+}
+
+// yamlEncodeChild writes value of the map key or list item.
+func yamlEncodeChild(w io.Writer, val interface{}, indent int) {
+	switch v := val.(type) {
+	case *yamlMap:
+		if len(v.keys) == 0 {
+			io.WriteString(w, " {}\n")
+			return
+		}
+		io.WriteString(w, "\n")
+		yamlEncode(w, v, indent+1)
+	case []interface{}:
+		if len(v) == 0 {
+			io.WriteString(w, " []\n")
+			return
+		}
+		io.WriteString(w, "\n")
+		yamlEncode(w, v, indent+1)
+	default:
+		io.WriteString(w, " "+yamlScalar(v)+"\n")
+	}
+}
+
+func yamlScalar(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(s)
+	case json.Number:
+		return s.String()
+	case string:
+		if yamlNeedsQuotes(s) {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+	return ""
+}
+
+// yamlNeedsQuotes reports whether string could be parsed as something else
+// than plain string when written without quotes. Only strings starting with
+// a letter and consisting of letters, digits, spaces and "_./-," are written
+// plain, which leaves out numbers, timestamps and special values like .inf,
+// .nan or ~ of YAML 1.1 and 1.2.
+func yamlNeedsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s || !yamlLetter(s[0]) {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return true
+	}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if !yamlLetter(c) && (c < '0' || c > '9') && !strings.ContainsRune(" _./-,", rune(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+func yamlLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package cli
+
+import "testing"
+
+func TestMarshalYAML(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+	}
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"scalar", "text", "text\n"},
+		{"quoted", "yes", "\"yes\"\n"},
+		{"number-string", "1.0", "\"1.0\"\n"},
+		{"empty-list", []string{}, "[]\n"},
+		{"list", []string{"a", "b"}, "- a\n- b\n"},
+		{"struct", item{"a: b", 1}, "name: \"a: b\"\nsize: 1\n"},
+		{"nested", struct {
+			Items []item          `json:"items"`
+			Map   map[string]bool `json:"map"`
+			Empty struct{}        `json:"empty"`
+		}{
+			Items: []item{{"a", 1}, {"b", 2}},
+			Map:   map[string]bool{"k": true},
+		}, "items:\n  - name: a\n    size: 1\n  - name: b\n    size: 2\nmap:\n  k: true\nempty: {}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := marshalYAML(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("marshalYAML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLQuoting(t *testing.T) {
+	tests := []struct {
+		in     string
+		quoted bool
+	}{
+		{"api", false},
+		{"web, ui", false},
+		{"Application used in tests.", false},
+		{"v1.2.3-rc.1", false},
+		{"1.2.3", true},
+		{".inf", true},
+		{"-.Inf", true},
+		{".nan", true},
+		{".NaN", true},
+		{"0x10", true},
+		{"0o17", true},
+		{"017", true},
+		{"1e3", true},
+		{"1_000", true},
+		{"12:30:00", true},
+		{"2018-03-06", true},
+		{"~", true},
+		{"null", true},
+		{"Null", true},
+		{"yes", true},
+		{"No", true},
+		{"on", true},
+		{"OFF", true},
+		{"y", true},
+		{"True", true},
+		{"a: b", true},
+		{"a #b", true},
+		{"https://example.com", true},
+		{"line\nbreak", true},
+		{"tab\t", true},
+		{"", true},
+		{" padded", true},
+		{"ünicode", true},
+	}
+	for _, tt := range tests {
+		got := yamlScalar(tt.in)
+		if quoted := got != tt.in; quoted != tt.quoted {
+			t.Errorf("yamlScalar(%q) = %s, want quoted %t", tt.in, got, tt.quoted)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+/*
+Package update checks release feed for newer versions of the application and
+replaces running executable with verified release artifact.
+
+Release feed is JSON document served over HTTP or read from local path:
+
+	{
+	  "name": "howi",
+	  "releases": [
+	    {
+	      "version": "1.2.0",
+	      "date": "2018-04-01T10:00:00Z",
+	      "notes": "bug fixes",
+	      "assets": [
+	        {
+	          "os": "linux",
+	          "arch": "amd64",
+	          "url": "howi-1.2.0-linux-amd64",
+	          "sha256": "8f434346648f6b96df89dda901c5176b10a6d83961dd3c1ac88b59b2dc327aa4",
+	          "signature": "howi-1.2.0-linux-amd64.asc"
+	        }
+	      ]
+	    }
+	  ]
+	}
+
+Relative asset and signature locations are resolved against location
+of the feed.
+*/
+package update
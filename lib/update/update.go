@@ -0,0 +1,310 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package update
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/digaverse/howi/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+)
+
+const (
+	// FmtErrFeedStatus formats error for unexpected HTTP response status.
+	FmtErrFeedStatus = "fetching %q failed with status %q"
+	// FmtErrNoAsset formats error when release has no artifact for current platform.
+	FmtErrNoAsset = "release %s has no asset for %s/%s"
+	// FmtErrMissingChecksum formats error when asset has no checksum.
+	FmtErrMissingChecksum = "asset %q has no sha256 checksum"
+	// FmtErrChecksumMismatch formats checksum verification error.
+	FmtErrChecksumMismatch = "checksum mismatch for %q want %s got %s"
+	// FmtErrMissingSignature formats error when signature is required but asset has none.
+	FmtErrMissingSignature = "asset %q has no signature"
+	// FmtErrInvalidSignature formats signature verification error.
+	FmtErrInvalidSignature = "invalid signature for %q: %s"
+	// FmtErrRemoteAsset formats error when remote feed refers to asset which is not HTTP(S) URL.
+	FmtErrRemoteAsset = "asset %q of remote feed must be HTTP(S) URL"
+)
+
+// Feed lists all published releases of the application.
+type Feed struct {
+	Name     string    `json:"name"`
+	Releases []Release `json:"releases"`
+}
+
+// Release is single published version of the application.
+type Release struct {
+	Version semver.Version `json:"version"`
+	Date    time.Time      `json:"date,omitempty"`
+	Notes   string         `json:"notes,omitempty"`
+	Assets  []Asset        `json:"assets"`
+}
+
+// Asset returns release artifact for given platform.
+func (r *Release) Asset(goos, goarch string) (*Asset, error) {
+	for i := range r.Assets {
+		if r.Assets[i].OS == goos && r.Assets[i].Arch == goarch {
+			return &r.Assets[i], nil
+		}
+	}
+	return nil, errors.Newf(FmtErrNoAsset, r.Version, goos, goarch)
+}
+
+// Asset is release artifact built for specific platform.
+type Asset struct {
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Updater checks release feed and applies updates.
+type Updater struct {
+	// Source is URL or path of the release feed.
+	Source string
+	// PublicKey is armored PGP public key. When set then every asset must
+	// have detached armored signature made with that key.
+	PublicKey string
+	// Prerelease enables updating to pre-release versions.
+	Prerelease bool
+	// Client is used for HTTP requests, defaults to http.DefaultClient.
+	Client *http.Client
+	// Executable is path of the file to replace, defaults to os.Executable.
+	Executable string
+}
+
+// Feed fetches and decodes release feed.
+func (u *Updater) Feed() (*Feed, error) {
+	rc, err := u.open(u.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	feed := &Feed{}
+	if err := json.NewDecoder(rc).Decode(feed); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// Check returns newest release which is greater than current version and
+// has asset for current platform. It returns nil release when current
+// version is up to date. Pre-release versions are ignored unless
+// Prerelease is enabled; semver precedence is used so that 1.0.0-rc.1
+// is older than 1.0.0.
+func (u *Updater) Check(current semver.Version) (*Release, error) {
+	feed, err := u.Feed()
+	if err != nil {
+		return nil, err
+	}
+	var latest *Release
+	for i := range feed.Releases {
+		rel := &feed.Releases[i]
+		if len(rel.Version.Pre) > 0 && !u.Prerelease {
+			continue
+		}
+		if !rel.Version.GT(current) {
+			continue
+		}
+		if _, err := rel.Asset(runtime.GOOS, runtime.GOARCH); err != nil {
+			continue
+		}
+		if latest == nil || rel.Version.GT(latest.Version) {
+			latest = rel
+		}
+	}
+	return latest, nil
+}
+
+// Apply downloads release asset for current platform, verifies it's checksum
+// and signature and atomically replaces the executable.
+func (u *Updater) Apply(rel *Release) error {
+	asset, err := rel.Asset(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	exe, err := u.executable()
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	// temporary file must be in same directory so that rename is atomic
+	tmp, err := ioutil.TempFile(filepath.Dir(exe), "."+filepath.Base(exe)+".new")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := u.download(asset, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+		return err
+	}
+	return replace(exe, tmp.Name())
+}
+
+// download writes asset to f and verifies it.
+func (u *Updater) download(asset *Asset, f *os.File) error {
+	if asset.SHA256 == "" {
+		return errors.Newf(FmtErrMissingChecksum, asset.URL)
+	}
+	loc, err := u.resolve(asset.URL)
+	if err != nil {
+		return err
+	}
+	rc, err := u.open(loc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), rc); err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, asset.SHA256) {
+		return errors.Newf(FmtErrChecksumMismatch, asset.URL, asset.SHA256, sum)
+	}
+	if u.PublicKey == "" {
+		return nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return u.verifySignature(asset, f)
+}
+
+func (u *Updater) verifySignature(asset *Asset, signed io.Reader) error {
+	if asset.Signature == "" {
+		return errors.Newf(FmtErrMissingSignature, asset.URL)
+	}
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(u.PublicKey))
+	if err != nil {
+		return err
+	}
+	loc, err := u.resolve(asset.Signature)
+	if err != nil {
+		return err
+	}
+	rc, err := u.open(loc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	sig, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, signed, bytes.NewReader(sig)); err != nil {
+		return errors.Newf(FmtErrInvalidSignature, asset.URL, err)
+	}
+	return nil
+}
+
+func (u *Updater) executable() (string, error) {
+	if u.Executable != "" {
+		return u.Executable, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// resolve relative location against location of the feed. Local paths are
+// allowed only when feed itself is local file, assets of remote feed must
+// resolve to HTTP(S) URL.
+func (u *Updater) resolve(loc string) (string, error) {
+	if isURL(loc) {
+		return loc, nil
+	}
+	if !isURL(u.Source) {
+		if filepath.IsAbs(loc) {
+			return loc, nil
+		}
+		return filepath.Join(filepath.Dir(u.Source), loc), nil
+	}
+	if filepath.IsAbs(loc) {
+		return "", errors.Newf(FmtErrRemoteAsset, loc)
+	}
+	base, err := url.Parse(u.Source)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+	resolved := base.ResolveReference(ref).String()
+	if !isURL(resolved) {
+		return "", errors.Newf(FmtErrRemoteAsset, loc)
+	}
+	return resolved, nil
+}
+
+// open returns reader for given URL or path.
+func (u *Updater) open(loc string) (io.ReadCloser, error) {
+	if !isURL(loc) {
+		return os.Open(loc)
+	}
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(loc)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Newf(FmtErrFeedStatus, loc, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func isURL(loc string) bool {
+	return strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://")
+}
+
+// replace executable with new file. Running executable can not be
+// overwritten on windows, but it can be renamed.
+func replace(exe, src string) error {
+	if runtime.GOOS != "windows" {
+		return os.Rename(src, exe)
+	}
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(src, exe); err != nil {
+		// try to restore previous executable
+		os.Rename(old, exe)
+		return err
+	}
+	return nil
+}
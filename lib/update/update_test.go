@@ -0,0 +1,274 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package update
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+var artifact = []byte("#!/bin/sh\necho new version\n")
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func newFeed(versions ...string) Feed {
+	feed := Feed{Name: "app"}
+	for _, v := range versions {
+		feed.Releases = append(feed.Releases, Release{
+			Version: semver.MustParse(v),
+			Assets: []Asset{{
+				OS:        runtime.GOOS,
+				Arch:      runtime.GOARCH,
+				URL:       "app-" + v,
+				SHA256:    checksum(artifact),
+				Signature: "app-" + v + ".asc",
+			}},
+		})
+	}
+	return feed
+}
+
+// newServer serves feed at /feed.json and artifact with optional signature
+// for any other path.
+func newServer(t *testing.T, feed Feed, sig []byte) *httptest.Server {
+	data, err := json.Marshal(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/feed.json":
+			w.Write(data)
+		case strings.HasSuffix(r.URL.Path, ".asc"):
+			if sig == nil {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(sig)
+		default:
+			w.Write(artifact)
+		}
+	}))
+}
+
+func newExecutable(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "howi-update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(dir, "app")
+	if err := ioutil.WriteFile(exe, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return exe
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    string
+		prerelease bool
+		versions   []string
+		want       string
+	}{
+		{"up-to-date", "1.2.0", false, []string{"1.0.0", "1.2.0"}, ""},
+		{"newer", "1.0.0", false, []string{"1.0.0", "1.1.0", "1.2.0"}, "1.2.0"},
+		{"ignore-prerelease", "1.0.0", false, []string{"1.1.0", "1.2.0-rc.1"}, "1.1.0"},
+		{"prerelease", "1.0.0", true, []string{"1.1.0", "1.2.0-rc.1"}, "1.2.0-rc.1"},
+		{"release-after-prerelease", "1.2.0-rc.1", false, []string{"1.2.0-rc.1", "1.2.0"}, "1.2.0"},
+		{"prerelease-order", "1.2.0-alpha.2", true, []string{"1.2.0-alpha.10", "1.2.0-alpha.1"}, "1.2.0-alpha.10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, newFeed(tt.versions...), nil)
+			defer srv.Close()
+			u := &Updater{Source: srv.URL + "/feed.json", Prerelease: tt.prerelease}
+			rel, err := u.Check(semver.MustParse(tt.current))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if rel != nil {
+					t.Errorf("Check want no update got %s", rel.Version)
+				}
+				return
+			}
+			if rel == nil || rel.Version.String() != tt.want {
+				t.Errorf("Check want %s got %v", tt.want, rel)
+			}
+		})
+	}
+}
+
+func TestCheckFeedPath(t *testing.T) {
+	exe := newExecutable(t)
+	defer os.RemoveAll(filepath.Dir(exe))
+	data, _ := json.Marshal(newFeed("2.0.0"))
+	feed := filepath.Join(filepath.Dir(exe), "feed.json")
+	if err := ioutil.WriteFile(feed, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(exe), "app-2.0.0"), artifact, 0644); err != nil {
+		t.Fatal(err)
+	}
+	u := &Updater{Source: feed, Executable: exe}
+	rel, err := u.Check(semver.MustParse("1.0.0"))
+	if err != nil || rel == nil {
+		t.Fatalf("Check want release got (%v, %v)", rel, err)
+	}
+	if err := u.Apply(rel); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadFile(exe)
+	if !bytes.Equal(got, artifact) {
+		t.Errorf("executable was not replaced got %q", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	const (
+		remote = "https://example.com/releases/feed.json"
+		local  = "/srv/releases/feed.json"
+	)
+	tests := []struct {
+		source  string
+		loc     string
+		want    string
+		wantErr bool
+	}{
+		{remote, "app-2.0.0", "https://example.com/releases/app-2.0.0", false},
+		{remote, "../app-2.0.0", "https://example.com/app-2.0.0", false},
+		{remote, "http://cdn.example.com/app-2.0.0", "http://cdn.example.com/app-2.0.0", false},
+		{remote, "/usr/local/bin/app", "", true},
+		{remote, "file:///usr/local/bin/app", "", true},
+		{remote, "ftp://example.com/app-2.0.0", "", true},
+		{local, "app-2.0.0", "/srv/releases/app-2.0.0", false},
+		{local, "/opt/app-2.0.0", "/opt/app-2.0.0", false},
+		{local, "https://example.com/app-2.0.0", "https://example.com/app-2.0.0", false},
+	}
+	for _, tt := range tests {
+		u := &Updater{Source: tt.source}
+		got, err := u.resolve(tt.loc)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("resolve(%q) from %q unexpected error %v", tt.loc, tt.source, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolve(%q) from %q want %q got %q", tt.loc, tt.source, tt.want, got)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	srv := newServer(t, newFeed("2.0.0"), nil)
+	defer srv.Close()
+	exe := newExecutable(t)
+	defer os.RemoveAll(filepath.Dir(exe))
+
+	u := &Updater{Source: srv.URL + "/feed.json", Executable: exe}
+	rel, err := u.Check(semver.MustParse("1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Apply(rel); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadFile(exe)
+	if !bytes.Equal(got, artifact) {
+		t.Errorf("executable was not replaced got %q", got)
+	}
+	info, _ := os.Stat(exe)
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0755 {
+		t.Errorf("executable mode want 0755 got %v", info.Mode().Perm())
+	}
+	files, _ := ioutil.ReadDir(filepath.Dir(exe))
+	if len(files) != 1 {
+		t.Errorf("temporary files should be removed got %d files", len(files))
+	}
+}
+
+func TestApplyChecksumMismatch(t *testing.T) {
+	feed := newFeed("2.0.0")
+	feed.Releases[0].Assets[0].SHA256 = checksum([]byte("other"))
+	srv := newServer(t, feed, nil)
+	defer srv.Close()
+	exe := newExecutable(t)
+	defer os.RemoveAll(filepath.Dir(exe))
+
+	u := &Updater{Source: srv.URL + "/feed.json", Executable: exe}
+	if err := u.Apply(&feed.Releases[0]); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Apply want checksum mismatch error got %v", err)
+	}
+	if got, _ := ioutil.ReadFile(exe); string(got) != "old" {
+		t.Errorf("executable should not be replaced got %q", got)
+	}
+}
+
+func TestApplySignature(t *testing.T) {
+	signer, err := openpgp.NewEntity("howi", "test", "howi@example.com", &packet.Config{RSABits: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pub bytes.Buffer
+	aw, _ := armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	signer.Serialize(aw)
+	aw.Close()
+
+	var sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sig, signer, bytes.NewReader(artifact), nil); err != nil {
+		t.Fatal(err)
+	}
+	var badSig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&badSig, signer, strings.NewReader("tampered"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		sig     []byte
+		wantErr bool
+	}{
+		{"valid", sig.Bytes(), false},
+		{"invalid", badSig.Bytes(), true},
+		{"missing", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := newFeed("2.0.0")
+			srv := newServer(t, feed, tt.sig)
+			defer srv.Close()
+			exe := newExecutable(t)
+			defer os.RemoveAll(filepath.Dir(exe))
+
+			u := &Updater{Source: srv.URL + "/feed.json", Executable: exe, PublicKey: pub.String()}
+			err := u.Apply(&feed.Releases[0])
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply error = %v, wantErr %t", err, tt.wantErr)
+			}
+			got, _ := ioutil.ReadFile(exe)
+			if replaced := bytes.Equal(got, artifact); replaced == tt.wantErr {
+				t.Errorf("executable replaced = %t, want %t", replaced, !tt.wantErr)
+			}
+		})
+	}
+}
@@ -57,6 +57,25 @@ func NewNotImplementedf(format string, v ...interface{}) NotImplementedErr {
 	return NotImplementedErr(Newf(format, v...))
 }
 
+// Is reports whether any error in err's chain matches target.
+// It calls errors.Is from the standard library.
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target, and if so,
+// sets target to that error value and returns true.
+// It calls errors.As from the standard library.
+func As(err error, target interface{}) bool {
+	return errors.As(err, target)
+}
+
+// Unwrap returns the result of calling the Unwrap method on err, if err's
+// type contains an Unwrap method returning error. Otherwise, Unwrap returns nil.
+func Unwrap(err error) error {
+	return errors.Unwrap(err)
+}
+
 // GetTypeOf provided error
 func GetTypeOf(err interface{}) string {
 	// remove pointer
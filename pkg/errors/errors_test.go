@@ -111,3 +111,19 @@ func testErrorFormat(t *testing.T, n int, arg interface{}, format, want string)
 		}
 	}
 }
+
+func TestIsAsUnwrap(t *testing.T) {
+	base := New("base")
+	wrapped := Newf("wrapped: %w", base)
+	if !Is(wrapped, base) {
+		t.Errorf("Is(%q, %q) want true", wrapped, base)
+	}
+	if got := Unwrap(wrapped); got != base {
+		t.Errorf("Unwrap(%q) want %q got %v", wrapped, base, got)
+	}
+	st := WithStackTrace("with trace")
+	var target *ErrorWithStackTrace
+	if !As(Newf("wrapped: %w", st), &target) || target != st {
+		t.Errorf("As should find *ErrorWithStackTrace got %v", target)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+// Names of encoders accepted by NewEncoder.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+const fmtErrUnknownFormat = "unknown log format %q, expected text, json or logfmt"
+
+// Record is logging event passed to Encoder.
+type Record struct {
+	Time   time.Time
+	Level  int
+	Label  string // name of the level e.g. "error" or "deprecated"
+	Msg    string
+	Fields []Field
+	Line   bool // written with Line methods or progress bar
+
+	sfx   []byte // label in human readable format
+	color []byte
+}
+
+// Encoder encodes records written by Logger.
+type Encoder interface {
+	// Encode appends record terminated with newline to buf.
+	Encode(buf []byte, r *Record) []byte
+}
+
+// NewEncoder returns encoder by format name.
+func NewEncoder(format string) (Encoder, error) {
+	switch format {
+	case FormatText:
+		return TextEncoder{}, nil
+	case FormatJSON:
+		return JSONEncoder{}, nil
+	case FormatLogfmt:
+		return LogfmtEncoder{}, nil
+	}
+	return nil, errors.Newf(fmtErrUnknownFormat, format)
+}
+
+// TextEncoder encodes records in human readable format with level labels.
+// Logger uses TextEncoder configured with Colors, Ts* and InitTerm methods
+// unless other encoder is set.
+type TextEncoder struct {
+	Colors    bool   // colorize labels and colored lines
+	Timestamp string // time layout, empty disables timestamp
+	Width     int    // align labels to the right edge of line, 0 disables
+}
+
+// Encode implements Encoder.
+func (e TextEncoder) Encode(buf []byte, r *Record) []byte {
+	start := len(buf)
+	sfx := r.sfx
+	pad := 2
+	if e.Colors && r.color != nil && sfx != nil {
+		sfx = append(append(append([]byte{}, r.color...), sfx...), reset...)
+		pad += len(r.color) + len(reset)
+	}
+	if !r.Line {
+		if e.Timestamp != "" {
+			buf = r.Time.AppendFormat(buf, e.Timestamp)
+		}
+		buf = append(buf, ' ')
+	}
+	if e.Width == 0 && sfx != nil {
+		buf = append(buf, sfx...)
+	}
+	buf = append(buf, ' ')
+	if e.Colors && r.color != nil && sfx == nil {
+		buf = append(buf, r.color...)
+		buf = append(buf, r.Msg...)
+		buf = append(buf, reset...)
+	} else {
+		buf = append(buf, r.Msg...)
+	}
+	for _, f := range r.Fields {
+		buf = append(buf, ' ')
+		buf = append(buf, f.String()...)
+	}
+	if e.Width > 0 && sfx != nil {
+		if padLen := e.Width - (len(buf) - start) - len(sfx) + pad; padLen > 0 {
+			buf = append(buf, bytes.Repeat([]byte{' '}, padLen)...)
+		}
+		buf = append(buf, sfx...)
+	}
+	return append(buf, _lf)
+}
+
+// JSONEncoder encodes records as JSON objects, one per line e.g.
+//
+//	{"time":"2018-06-01T10:00:00.5+03:00","level":"info","msg":"done","task":"upload"}
+type JSONEncoder struct{}
+
+// Encode implements Encoder.
+func (JSONEncoder) Encode(buf []byte, r *Record) []byte {
+	buf = append(buf, `{"time":`...)
+	buf = appendJSON(buf, r.Time.Format(time.RFC3339Nano))
+	buf = append(buf, `,"level":`...)
+	buf = appendJSON(buf, r.label())
+	buf = append(buf, `,"msg":`...)
+	buf = appendJSON(buf, r.Msg)
+	for _, f := range r.Fields {
+		buf = append(buf, ',')
+		buf = appendJSON(buf, f.Key)
+		buf = append(buf, ':')
+		buf = appendJSON(buf, jsonValue(f.Value))
+	}
+	return append(buf, '}', _lf)
+}
+
+// LogfmtEncoder encodes records as logfmt key=value pairs, one per line e.g.
+//
+//	time=2018-06-01T10:00:00.5+03:00 level=info msg=done task=upload
+type LogfmtEncoder struct{}
+
+// Encode implements Encoder.
+func (LogfmtEncoder) Encode(buf []byte, r *Record) []byte {
+	buf = append(buf, "time="...)
+	buf = append(buf, r.Time.Format(time.RFC3339Nano)...)
+	buf = append(buf, " level="...)
+	buf = append(buf, r.label()...)
+	buf = append(buf, " msg="...)
+	buf = append(buf, fieldValue(r.Msg)...)
+	for _, f := range r.Fields {
+		buf = append(buf, ' ')
+		buf = append(buf, f.String()...)
+	}
+	return append(buf, _lf)
+}
+
+// label returns level label of the record, lines are labeled as "line".
+func (r *Record) label() string {
+	if r.Label == "" {
+		return "line"
+	}
+	return r.Label
+}
+
+// jsonValue returns value of the field suitable for json.Marshal. Errors
+// and fmt.Stringers not implementing json.Marshaler are written as strings.
+func jsonValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Marshaler:
+		return val
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	}
+	return v
+}
+
+func appendJSON(buf []byte, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return append(buf, data...)
+}
+
+// levelLabel returns name of the level from its human readable label
+// e.g. "[ ✗ error     ]" → "error".
+func levelLabel(sfx []byte) string {
+	return string(bytes.TrimSpace(sfx[6 : len(sfx)-1]))
+}
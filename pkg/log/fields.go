@@ -0,0 +1,230 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field is key/value pair attached to log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns field in key=value form, value is quoted when needed.
+func (f Field) String() string {
+	return f.Key + "=" + fieldValue(f.Value)
+}
+
+// missingValue is value of the last key when odd number of key/value
+// arguments is passed.
+const missingValue = "(MISSING)"
+
+// Fields converts alternating keys and values to fields. Non string key is
+// converted with fmt.Sprint and last key without value gets value (MISSING).
+func Fields(keyvals ...interface{}) []Field {
+	fields := make([]Field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		f := Field{Key: fmt.Sprint(keyvals[i]), Value: missingValue}
+		if i+1 < len(keyvals) {
+			f.Value = keyvals[i+1]
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func fieldValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// With returns child logger which attaches fields given as alternating
+// keys and values to every message e.g.
+//
+//	l.With("task", name).Info("done", "elapsed", d)
+func (l *Logger) With(keyvals ...interface{}) *Entry {
+	return &Entry{l: l, fields: Fields(keyvals...)}
+}
+
+// Entry is child logger carrying fields. Messages are written to the parent
+// logger with its level and output. Level methods accept message followed
+// by alternating keys and values of additional fields, formatted variants
+// handle arguments in the manner of fmt.Printf and carry only fields of
+// the Entry.
+type Entry struct {
+	l      *Logger
+	fields []Field
+}
+
+// With returns child logger with fields of this logger and given fields.
+func (e *Entry) With(keyvals ...interface{}) *Entry {
+	fields := make([]Field, 0, len(e.fields)+len(keyvals)/2)
+	fields = append(fields, e.fields...)
+	return &Entry{l: e.l, fields: append(fields, Fields(keyvals...)...)}
+}
+
+// Fields returns fields attached to the logger.
+func (e *Entry) Fields() []Field {
+	return append([]Field(nil), e.fields...)
+}
+
+// Logger returns parent logger.
+func (e *Entry) Logger() *Logger {
+	return e.l
+}
+
+// Panic writes message with fields on PANIC level followed by a call to
+// panic().
+func (e *Entry) Panic(msg string, keyvals ...interface{}) {
+	e.log(PANIC, sfxPanic[:], red, msg, keyvals)
+	panic(msg)
+}
+
+// Panicf writes formatted message with fields on PANIC level followed by a
+// call to panic().
+func (e *Entry) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	e.log(PANIC, sfxPanic[:], red, msg, nil)
+	panic(msg)
+}
+
+// Fatal writes message with fields on FATAL level followed by exit with
+// status 1.
+func (e *Entry) Fatal(msg string, keyvals ...interface{}) {
+	e.log(FATAL, sfxFatal[:], red, msg, keyvals)
+	e.l.Exit(1)
+}
+
+// Fatalf writes formatted message with fields on FATAL level followed by
+// exit with status 1.
+func (e *Entry) Fatalf(format string, v ...interface{}) {
+	e.log(FATAL, sfxFatal[:], red, fmt.Sprintf(format, v...), nil)
+	e.l.Exit(1)
+}
+
+// Emergency writes message with fields on EMERGENCY level.
+func (e *Entry) Emergency(msg string, keyvals ...interface{}) {
+	e.log(EMERGENCY, sfxEmergency[:], red, msg, keyvals)
+}
+
+// Emergencyf writes formatted message with fields on EMERGENCY level.
+func (e *Entry) Emergencyf(format string, v ...interface{}) {
+	e.logf(EMERGENCY, sfxEmergency[:], red, format, v)
+}
+
+// Deprecated writes message with fields on EMERGENCY level marking use of deprecated API.
+func (e *Entry) Deprecated(msg string, keyvals ...interface{}) {
+	e.log(EMERGENCY, sfxDeprecated[:], red, msg, keyvals)
+}
+
+// Deprecatedf writes formatted message with fields on EMERGENCY level marking use of deprecated API.
+func (e *Entry) Deprecatedf(format string, v ...interface{}) {
+	e.logf(EMERGENCY, sfxDeprecated[:], red, format, v)
+}
+
+// Alert writes message with fields on ALERT level.
+func (e *Entry) Alert(msg string, keyvals ...interface{}) {
+	e.log(ALERT, sfxAlert[:], red, msg, keyvals)
+}
+
+// Alertf writes formatted message with fields on ALERT level.
+func (e *Entry) Alertf(format string, v ...interface{}) {
+	e.logf(ALERT, sfxAlert[:], red, format, v)
+}
+
+// Critical writes message with fields on CRITICAL level.
+func (e *Entry) Critical(msg string, keyvals ...interface{}) {
+	e.log(CRITICAL, sfxCritical[:], red, msg, keyvals)
+}
+
+// Criticalf writes formatted message with fields on CRITICAL level.
+func (e *Entry) Criticalf(format string, v ...interface{}) {
+	e.logf(CRITICAL, sfxCritical[:], red, format, v)
+}
+
+// Error writes message with fields on ERROR level.
+func (e *Entry) Error(msg string, keyvals ...interface{}) {
+	e.log(ERROR, sfxError[:], red, msg, keyvals)
+}
+
+// Errorf writes formatted message with fields on ERROR level.
+func (e *Entry) Errorf(format string, v ...interface{}) {
+	e.logf(ERROR, sfxError[:], red, format, v)
+}
+
+// Warning writes message with fields on WARNING level.
+func (e *Entry) Warning(msg string, keyvals ...interface{}) {
+	e.log(WARNING, sfxWarning[:], yellow, msg, keyvals)
+}
+
+// Warningf writes formatted message with fields on WARNING level.
+func (e *Entry) Warningf(format string, v ...interface{}) {
+	e.logf(WARNING, sfxWarning[:], yellow, format, v)
+}
+
+// Notice writes message with fields on NOTICE level.
+func (e *Entry) Notice(msg string, keyvals ...interface{}) {
+	e.log(NOTICE, sfxNotice[:], cyan, msg, keyvals)
+}
+
+// Noticef writes formatted message with fields on NOTICE level.
+func (e *Entry) Noticef(format string, v ...interface{}) {
+	e.logf(NOTICE, sfxNotice[:], cyan, format, v)
+}
+
+// Info writes message with fields on INFO level.
+func (e *Entry) Info(msg string, keyvals ...interface{}) {
+	e.log(INFO, sfxInfo[:], cyan, msg, keyvals)
+}
+
+// Infof writes formatted message with fields on INFO level.
+func (e *Entry) Infof(format string, v ...interface{}) {
+	e.logf(INFO, sfxInfo[:], cyan, format, v)
+}
+
+// Ok writes message with fields on OK level.
+func (e *Entry) Ok(msg string, keyvals ...interface{}) {
+	e.log(OK, sfxOk[:], green, msg, keyvals)
+}
+
+// Okf writes formatted message with fields on OK level.
+func (e *Entry) Okf(format string, v ...interface{}) {
+	e.logf(OK, sfxOk[:], green, format, v)
+}
+
+// Debug writes message with fields on DEBUG level.
+func (e *Entry) Debug(msg string, keyvals ...interface{}) {
+	e.log(DEBUG, sfxDebug[:], white, msg, keyvals)
+}
+
+// Debugf writes formatted message with fields on DEBUG level.
+func (e *Entry) Debugf(format string, v ...interface{}) {
+	e.logf(DEBUG, sfxDebug[:], white, format, v)
+}
+
+func (e *Entry) log(level int, sfx []byte, color []byte, msg string, keyvals []interface{}) {
+	if !e.l.enabled(level) {
+		return
+	}
+	fields := e.fields
+	if len(keyvals) > 0 {
+		fields = append(e.Fields(), Fields(keyvals...)...)
+	}
+	e.l.write(level, msg, fields, sfx, color)
+}
+
+func (e *Entry) logf(level int, sfx []byte, color []byte, format string, v []interface{}) {
+	if !e.l.enabled(level) {
+		return
+	}
+	e.l.write(level, fmt.Sprintf(format, v...), e.fields, sfx, color)
+}
@@ -0,0 +1,298 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+const (
+	// backupTimeLayout is layout of the time in the names of rotated files.
+	backupTimeLayout = "2006-01-02T15-04-05.000000000"
+	fmtErrFileOpen   = "failed to open log file %q: %s"
+)
+
+// FileConfig configures rotating log file.
+type FileConfig struct {
+	Path       string
+	MaxSize    int64         // rotate before file exceeds size in bytes, 0 disables
+	MaxAge     time.Duration // rotate when file was opened longer ago, 0 disables
+	MaxBackups int           // number of rotated files to keep, 0 keeps all
+	Compress   bool          // gzip rotated files
+}
+
+// File is log file which can be used as output of the Logger or Sink.
+// Rotated file is renamed to name-<time>.ext e.g. howi-2018-06-01T10-00-00.000000000.log
+// and optionally compressed in background. File is reopened on SIGHUP, so it
+// can be rotated also with external tools like logrotate, logger does not
+// exit on SIGHUP while its output or sink is open File. File is safe for
+// concurrent use.
+type File struct {
+	mu     sync.Mutex
+	cfg    FileConfig
+	f      *os.File
+	size   int64
+	opened time.Time
+	now    func() time.Time
+	sigc   chan os.Signal
+	mill   sync.WaitGroup // compressing and removing backups
+	millMu sync.Mutex
+	err    error // first error of compressing or removing backups
+}
+
+// OpenFile opens or creates log file described by cfg for appending.
+func OpenFile(cfg FileConfig) (*File, error) {
+	f := &File{cfg: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	f.sigc = make(chan os.Signal, 1)
+	signal.Notify(f.sigc, syscall.SIGHUP)
+	go func() {
+		for range f.sigc {
+			f.Reopen()
+		}
+	}()
+	return f, nil
+}
+
+// Write writes p to the file rotating it first when p would exceed
+// MaxSize or file is older than MaxAge.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && (f.cfg.MaxSize > 0 && f.size+int64(len(p)) > f.cfg.MaxSize ||
+		f.cfg.MaxAge > 0 && f.now().Sub(f.opened) >= f.cfg.MaxAge) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.f.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes current file, renames it as backup and opens new file.
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Reopen opens the file again e.g. after it was moved by external tool.
+// Current file is closed only when new one was opened, so writing continues
+// to current file when opening fails.
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return os.ErrClosed
+	}
+	current := f.f
+	if err := f.open(); err != nil {
+		return err
+	}
+	return current.Close()
+}
+
+// Close closes the file and waits until rotated files are compressed.
+// It returns error of closing the file or first error of compressing or
+// removing old backups.
+func (f *File) Close() error {
+	f.mu.Lock()
+	if f.f == nil {
+		f.mu.Unlock()
+		return os.ErrClosed
+	}
+	signal.Stop(f.sigc)
+	close(f.sigc)
+	err := f.f.Close()
+	f.f = nil
+	f.mu.Unlock()
+	f.mill.Wait()
+	if err != nil {
+		return err
+	}
+	return f.err
+}
+
+// isOpen reports whether file was not closed.
+func (f *File) isOpen() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f != nil
+}
+
+// hasFile reports whether output or any sink of the logger is open File.
+func (l *Logger) hasFile() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	writers := []io.Writer{l.w, l.lineW}
+	for _, r := range l.routes {
+		writers = append(writers, r.w)
+	}
+	for _, s := range l.sinks {
+		writers = append(writers, s.W)
+	}
+	for _, w := range writers {
+		if f, ok := w.(*File); ok && f.isOpen() {
+			return true
+		}
+	}
+	return false
+}
+
+// Backups returns paths of rotated files sorted from oldest to newest.
+func (f *File) Backups() ([]string, error) {
+	dir := filepath.Dir(f.cfg.Path)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix, ext := f.backupAffixes()
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimSuffix(name[len(prefix):], ".gz"), ext)
+		if _, err := time.Parse(backupTimeLayout, ts); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// open opens the file for appending, fields of f are not changed when it
+// fails. Age of existing file is counted from its modification time.
+// Caller must hold f.mu.
+func (f *File) open() error {
+	file, err := os.OpenFile(f.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Newf(fmtErrFileOpen, f.cfg.Path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Newf(fmtErrFileOpen, f.cfg.Path, err)
+	}
+	f.f, f.size, f.opened = file, info.Size(), f.now()
+	if f.size > 0 {
+		f.opened = info.ModTime()
+	}
+	return nil
+}
+
+// rotate renames current file as backup and opens new file. Caller must
+// hold f.mu.
+func (f *File) rotate() error {
+	if err := f.f.Close(); err != nil {
+		return err
+	}
+	prefix, ext := f.backupAffixes()
+	backup := filepath.Join(filepath.Dir(f.cfg.Path), prefix+f.now().Format(backupTimeLayout)+ext)
+	if err := os.Rename(f.cfg.Path, backup); err != nil {
+		// keep writing to current file
+		if oerr := f.open(); oerr != nil {
+			return oerr
+		}
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.mill.Add(1)
+	go func() {
+		defer f.mill.Done()
+		f.millBackups()
+	}()
+	return nil
+}
+
+// millBackups compresses rotated files and removes backups exceeding
+// MaxBackups.
+func (f *File) millBackups() {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+	backups, err := f.Backups()
+	if err != nil {
+		f.setErr(err)
+		return
+	}
+	if f.cfg.Compress {
+		for i, backup := range backups {
+			if !strings.HasSuffix(backup, ".gz") {
+				f.setErr(compressFile(backup))
+				backups[i] = backup + ".gz"
+			}
+		}
+	}
+	for f.cfg.MaxBackups > 0 && len(backups) > f.cfg.MaxBackups {
+		f.setErr(os.Remove(backups[0]))
+		backups = backups[1:]
+	}
+}
+
+func (f *File) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+// backupAffixes returns prefix and extension of backup file names.
+func (f *File) backupAffixes() (prefix, ext string) {
+	name := filepath.Base(f.cfg.Path)
+	ext = filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// compressFile replaces file at path with gzipped file path.gz.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,268 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func tempLogFile(t *testing.T, cfg FileConfig) (*File, string) {
+	dir, err := ioutil.TempDir("", "howi-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	cfg.Path = filepath.Join(dir, "app.log")
+	f, err := OpenFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, dir
+}
+
+// readLogFile returns content of log file decompressing gzipped files.
+func readLogFile(t *testing.T, path string) string {
+	r, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if !strings.HasSuffix(path, ".gz") {
+		b, _ := ioutil.ReadAll(r)
+		return string(b)
+	}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestFileRotateBySize(t *testing.T) {
+	tests := []struct {
+		name     string
+		compress bool
+		ext      string
+	}{
+		{"plain", false, ".log"},
+		{"gzip", true, ".log.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := tempLogFile(t, FileConfig{MaxSize: 20, MaxBackups: 2, Compress: tt.compress})
+			for i := 1; i <= 7; i++ {
+				fmt.Fprintf(f, "line %d..\n", i) // 2 lines per file
+			}
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+			backups, err := f.Backups()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(backups) != 2 {
+				t.Fatalf("want 2 backups got %v", backups)
+			}
+			for i, want := range []string{"line 3..\nline 4..\n", "line 5..\nline 6..\n"} {
+				if !strings.HasSuffix(backups[i], tt.ext) {
+					t.Errorf("backup %q should have extension %s", backups[i], tt.ext)
+				}
+				if got := readLogFile(t, backups[i]); got != want {
+					t.Errorf("backup %d want %q got %q", i, want, got)
+				}
+			}
+			if got := readLogFile(t, f.cfg.Path); got != "line 7..\n" {
+				t.Errorf("log file want last line got %q", got)
+			}
+		})
+	}
+}
+
+func TestFileRotateByAge(t *testing.T) {
+	f, _ := tempLogFile(t, FileConfig{MaxAge: time.Hour})
+	now := time.Now()
+	f.now = func() time.Time { return now }
+	f.Reopen()
+	f.Write([]byte("old\n"))
+	now = now.Add(30 * time.Minute)
+	f.Write([]byte("recent\n"))
+	now = now.Add(30 * time.Minute)
+	f.Write([]byte("new\n"))
+	f.Close()
+	backups, _ := f.Backups()
+	if len(backups) != 1 || readLogFile(t, backups[0]) != "old\nrecent\n" {
+		t.Fatalf("want one backup with old lines got %v", backups)
+	}
+	if !strings.Contains(backups[0], now.Format(backupTimeLayout)) {
+		t.Errorf("backup name should contain rotation time got %s", backups[0])
+	}
+	if got := readLogFile(t, f.cfg.Path); got != "new\n" {
+		t.Errorf("log file want new line got %q", got)
+	}
+}
+
+func TestFileAgeOfExistingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "howi-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	if err := ioutil.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenFile(FileConfig{Path: path, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("new\n"))
+	f.Close()
+	backups, _ := f.Backups()
+	if len(backups) != 1 || readLogFile(t, backups[0]) != "old\n" {
+		t.Fatalf("file older than MaxAge should be rotated on first write got %v", backups)
+	}
+	if got := readLogFile(t, path); got != "new\n" {
+		t.Errorf("log file want new line got %q", got)
+	}
+}
+
+func TestFileReopenOnSIGHUP(t *testing.T) {
+	f, dir := tempLogFile(t, FileConfig{})
+	defer f.Close()
+	f.Write([]byte("before\n"))
+	moved := filepath.Join(dir, "app.log.1")
+	if err := os.Rename(f.cfg.Path, moved); err != nil {
+		t.Fatal(err)
+	}
+	// signal is delivered to the file only, loggers without file would
+	// exit on SIGHUP sent to the process
+	f.sigc <- syscall.SIGHUP
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(f.cfg.Path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	f.Write([]byte("after\n"))
+	if got := readLogFile(t, moved); got != "before\n" {
+		t.Errorf("moved file want %q got %q", "before\n", got)
+	}
+	if got := readLogFile(t, f.cfg.Path); got != "after\n" {
+		t.Errorf("reopened file want %q got %q", "after\n", got)
+	}
+}
+
+func TestFileReopenFailure(t *testing.T) {
+	f, dir := tempLogFile(t, FileConfig{})
+	defer f.Close()
+	moved := dir + ".moved"
+	if err := os.Rename(dir, moved); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(moved)
+	if err := f.Reopen(); err == nil {
+		t.Fatal("reopen should fail when directory is missing")
+	}
+	if _, err := f.Write([]byte("kept\n")); err != nil {
+		t.Fatalf("write should continue to current file got %v", err)
+	}
+	if err := os.Rename(moved, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("reopened\n"))
+	if got := readLogFile(t, f.cfg.Path); got != "kept\nreopened\n" {
+		t.Errorf("log file want both lines got %q", got)
+	}
+}
+
+func TestSIGHUPWithFile(t *testing.T) {
+	f, _ := tempLogFile(t, FileConfig{})
+	defer f.Close()
+	withFile := New(nil, QUIET)
+	withFile.AddSink("file", Sink{W: f, Level: INFO})
+	withoutFile := New(nil, QUIET)
+	exits := make(chan string, 2)
+	withFile.SetExitFunc(func(int) { exits <- "with file" })
+	withoutFile.SetExitFunc(func(int) { exits <- "without file" })
+	for _, l := range []*Logger{withFile, withoutFile} {
+		sigc := make(chan os.Signal, 2)
+		sigc <- syscall.SIGHUP
+		sigc <- syscall.SIGTERM
+		close(sigc)
+		l.handleSignals(sigc)
+	}
+	// logger with file ignores SIGHUP and exits on SIGTERM
+	if got := []string{<-exits, <-exits}; got[0] != "with file" || got[1] != "without file" || len(exits) != 0 {
+		t.Errorf("each logger should exit once got %v and %d more", got, len(exits))
+	}
+
+	f.Close()
+	sigc := make(chan os.Signal, 1)
+	sigc <- syscall.SIGHUP
+	close(sigc)
+	withFile.handleSignals(sigc)
+	if len(exits) != 1 {
+		t.Error("logger should exit on SIGHUP after its file was closed")
+	}
+}
+
+func TestFileConcurrentWrites(t *testing.T) {
+	f, dir := tempLogFile(t, FileConfig{MaxSize: 512})
+	l := New(nil, QUIET)
+	l.AddSink("file", Sink{W: f, Level: INFO, Encoder: LogfmtEncoder{}})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.With("task", i).Info("write", "n", j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Err(); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "app*.log"))
+	if len(files) < 2 {
+		t.Fatalf("file should be rotated got %v", files)
+	}
+	lines := 0
+	for _, name := range files {
+		for _, line := range strings.Split(strings.TrimSpace(readLogFile(t, name)), "\n") {
+			if !strings.Contains(line, "msg=write task=") {
+				t.Errorf("corrupted line %q in %s", line, name)
+			}
+			lines++
+		}
+	}
+	if lines != 400 {
+		t.Errorf("want 400 lines got %d", lines)
+	}
+}
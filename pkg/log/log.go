@@ -42,6 +42,8 @@ const (
 	DEBUG = INFO + 1
 	// LINE (7) Normal non verbose output line
 	LINE = NOTICE
+	// lineLevel is level of lines which are written without prefix
+	lineLevel = -1
 	// CR
 	_cr uint8 = 13
 	// newline
@@ -49,6 +51,9 @@ const (
 	t0  uint8 = 0 // Disable timestamp
 	t1  uint8 = 1 // Prefix standard timestamp
 	t2  uint8 = 2 // Prefix time only
+	// time layouts of text encoder
+	tsLayoutStandard = "2006-01-02 15:04:05"
+	tsLayoutTime     = "15:04:05"
 )
 
 var (
@@ -75,8 +80,6 @@ var (
 	cyan          = []byte{esc, 91, 51, 54, 109}
 	white         = []byte{esc, 91, 51, 55, 109}
 	reset         = []byte{esc, 91, 48, 109}
-	padDef        = 2
-	debug         = false
 )
 
 // New creates a new Logger. The w variable sets the
@@ -84,13 +87,10 @@ var (
 // The level argument sets log level
 func New(w io.Writer, level int) *Logger {
 	l := &Logger{
-		w:       w,
-		level:   level,
-		exit:    os.Exit,
-		wt:      t1,
-		ts:      &timestamp{},
-		started: timestamp{},
-		prfx:    []byte(" "),
+		w:     w,
+		level: level,
+		exit:  os.Exit,
+		wt:    t1,
 	}
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc,
@@ -99,11 +99,28 @@ func New(w io.Writer, level int) *Logger {
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 	go func() {
-		<-sigc
+		l.handleSignals(sigc)
+		signal.Stop(sigc)
 		close(sigc)
-		l.Exit(0)
 	}()
-	l.started.now(t0)
+	return l
+}
+
+// handleSignals exits on first signal received from sigc. SIGHUP is ignored
+// while output or sink of the logger is open File which reopens on it.
+func (l *Logger) handleSignals(sigc <-chan os.Signal) {
+	for sig := range sigc {
+		if sig != syscall.SIGHUP || !l.hasFile() {
+			l.Exit(0)
+			return
+		}
+	}
+}
+
+// NewWithEncoder creates a new Logger writing log messages encoded with enc.
+func NewWithEncoder(w io.Writer, level int, enc Encoder) *Logger {
+	l := New(w, level)
+	l.enc = enc
 	return l
 }
 
@@ -163,6 +180,31 @@ func SetOutput(w io.Writer) {
 	std.SetOutput(w)
 }
 
+// SetLevelOutput calls std.SetLevelOutput
+func SetLevelOutput(level int, w io.Writer) {
+	std.SetLevelOutput(level, w)
+}
+
+// SetLineOutput calls std.SetLineOutput
+func SetLineOutput(w io.Writer) {
+	std.SetLineOutput(w)
+}
+
+// AddSink calls std.AddSink
+func AddSink(name string, s Sink) {
+	std.AddSink(name, s)
+}
+
+// RemoveSink calls std.RemoveSink
+func RemoveSink(name string) {
+	std.RemoveSink(name)
+}
+
+// SetEncoder calls std.SetEncoder
+func SetEncoder(enc Encoder) {
+	std.SetEncoder(enc)
+}
+
 // TsDisabled calls std.TsDisabled
 func TsDisabled() {
 	std.TsDisabled()
@@ -300,16 +342,12 @@ func Okf(format string, v ...interface{}) {
 
 // Debug calls std.Debug
 func Debug(v ...interface{}) {
-	if debug {
-		std.Debug(v...)
-	}
+	std.Debug(v...)
 }
 
 // Debugf calls std.Debugf
 func Debugf(format string, v ...interface{}) {
-	if debug {
-		std.Debugf(format, v...)
-	}
+	std.Debugf(format, v...)
 }
 
 // SetPrimaryColor calls std.SetPrimaryColor
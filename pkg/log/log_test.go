@@ -6,11 +6,15 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/digaverse/howi/pkg/errors"
 )
 
 const testString = "Dummy log message to have something to log, length of this string is avarage message length."
@@ -559,3 +563,347 @@ func TestProgress(t *testing.T) {
 		pr.Next()
 	}
 }
+
+func TestLevelOutput(t *testing.T) {
+	var out, errOut, warnOut, lines bytes.Buffer
+	l := New(&out, INFO)
+	l.TsDisabled()
+	l.SetLevelOutput(ERROR, &errOut)
+	l.SetLevelOutput(WARNING, &warnOut)
+	l.SetLineOutput(&lines)
+	l.Critical("critical")
+	l.Error("error")
+	l.Warning("warning")
+	l.Notice("notice")
+	l.Info("info")
+	l.Line("line")
+	l.ColoredLine("colored")
+
+	tests := []struct {
+		name string
+		buf  *bytes.Buffer
+		want []string
+	}{
+		{"errors", &errOut, []string{"critical", "error"}},
+		{"warnings", &warnOut, []string{"warning"}},
+		{"default", &out, []string{"notice", "info"}},
+		{"lines", &lines, []string{"line", "colored"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(tt.buf.String()), "\n") {
+			fields := strings.Fields(line)
+			got = append(got, fields[len(fields)-1])
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s output want %v got %v", tt.name, tt.want, got)
+		}
+	}
+	if l.LineOutput() != &lines {
+		t.Error("LineOutput should return line output")
+	}
+
+	// removing route and SetOutput resets outputs
+	l.SetLevelOutput(WARNING, nil)
+	l.Warning("warning")
+	if !strings.HasSuffix(out.String(), "warning\n") {
+		t.Error("warning should be written to default output after removing warning output")
+	}
+	var all bytes.Buffer
+	l.SetOutput(&all)
+	l.Error("error")
+	l.Line("line")
+	if got := strings.Count(all.String(), "\n"); got != 2 {
+		t.Errorf("SetOutput should reset outputs, want 2 lines got %d", got)
+	}
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, INFO)
+	l.TsDisabled()
+	task := l.With("phase", "do").With("task", "upload")
+	task.Info("done", "elapsed", 2*time.Second, "dest", "s3 bucket", "odd")
+	l.With("ignored", "level").Debug("debug")
+	want := `done phase=do task=upload elapsed=2s dest="s3 bucket" odd=(MISSING)`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want line containing %q got %q", want, buf.String())
+	}
+	if strings.Contains(buf.String(), "ignored") {
+		t.Error("debug message should not be written on INFO level")
+	}
+	if n := len(task.Fields()); n != 2 {
+		t.Errorf("child logger should carry 2 fields got %d", n)
+	}
+	if task.Logger() != l {
+		t.Error("child logger should write to parent logger")
+	}
+}
+
+func TestEntryLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWithEncoder(&buf, DEBUG, LogfmtEncoder{})
+	e := l.With("phase", "do")
+	tests := []struct {
+		name string
+		log  func()
+		want string
+	}{
+		{"emergency", func() { e.Emergency("down", "n", 1) }, "level=emergency msg=down phase=do n=1"},
+		{"emergencyf", func() { e.Emergencyf("down %d", 1) }, `level=emergency msg="down 1" phase=do`},
+		{"deprecated", func() { e.Deprecated("old", "n", 1) }, "level=deprecated msg=old phase=do n=1"},
+		{"deprecatedf", func() { e.Deprecatedf("old %d", 1) }, `level=deprecated msg="old 1" phase=do`},
+		{"alert", func() { e.Alert("disk", "n", 1) }, "level=alert msg=disk phase=do n=1"},
+		{"alertf", func() { e.Alertf("disk %d", 1) }, `level=alert msg="disk 1" phase=do`},
+		{"critical", func() { e.Critical("db", "n", 1) }, "level=critical msg=db phase=do n=1"},
+		{"criticalf", func() { e.Criticalf("db %d", 1) }, `level=critical msg="db 1" phase=do`},
+		{"errorf", func() { e.Errorf("failed %s", "upload") }, `level=error msg="failed upload" phase=do`},
+		{"warningf", func() { e.Warningf("retry %d", 2) }, `level=warning msg="retry 2" phase=do`},
+		{"noticef", func() { e.Noticef("deploy %s", "api") }, `level=notice msg="deploy api" phase=do`},
+		{"infof", func() { e.Infof("took %s", time.Second) }, `level=info msg="took 1s" phase=do`},
+		{"okf", func() { e.Okf("done %d", 3) }, `level=ok msg="done 3" phase=do`},
+		{"debugf", func() { e.Debugf("step %d", 4) }, `level=debug msg="step 4" phase=do`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			tt.log()
+			if !strings.HasSuffix(buf.String(), tt.want+"\n") {
+				t.Errorf("want line ending with %q got %q", tt.want, buf.String())
+			}
+		})
+	}
+
+	code := -1
+	l.SetExitFunc(func(c int) { code = c })
+	buf.Reset()
+	e.Fatalf("exit %d", 1)
+	if code != 1 || !strings.HasSuffix(buf.String(), `level=fatal msg="exit 1" phase=do`+"\n") {
+		t.Errorf("fatal should write message and exit with 1 got %d %q", code, buf.String())
+	}
+	defer func() {
+		if r := recover(); r != "boom 1" {
+			t.Errorf("panic want %q got %v", "boom 1", r)
+		}
+	}()
+	e.Panicf("boom %d", 1)
+}
+
+func TestEncoders(t *testing.T) {
+	ts := time.Date(2018, 6, 1, 10, 0, 0, 500000000, time.UTC)
+	record := &Record{
+		Time:   ts,
+		Level:  ERROR,
+		Label:  "error",
+		Msg:    "upload failed",
+		Fields: Fields("task", "upload", "err", fmt.Errorf("connection \"reset\""), "elapsed", 2*time.Second),
+		sfx:    sfxError[:],
+		color:  red,
+	}
+	line := &Record{Time: ts, Level: LINE, Msg: "api 1.2.0", Line: true}
+	tests := []struct {
+		name string
+		enc  Encoder
+		r    *Record
+		want string
+	}{
+		{"text", TextEncoder{}, record,
+			" [ ✗ error     ] upload failed task=upload err=\"connection \\\"reset\\\"\" elapsed=2s\n"},
+		{"text-timestamp", TextEncoder{Timestamp: "15:04:05"}, line, " api 1.2.0\n"},
+		{"text-aligned", TextEncoder{Timestamp: "15:04:05", Width: 40},
+			&Record{Time: ts, Level: OK, Label: "ok", Msg: "done", sfx: sfxOk[:]},
+			"10:00:00  done           [ ✔ ok        ]\n"},
+		{"text-colors", TextEncoder{Colors: true}, &Record{Msg: "colored", Line: true, color: blue},
+			" " + string(blue) + "colored" + string(reset) + "\n"},
+		{"json", JSONEncoder{}, record,
+			`{"time":"2018-06-01T10:00:00.5Z","level":"error","msg":"upload failed","task":"upload",` +
+				`"err":"connection \"reset\"","elapsed":"2s"}` + "\n"},
+		{"json-line", JSONEncoder{}, line, `{"time":"2018-06-01T10:00:00.5Z","level":"line","msg":"api 1.2.0"}` + "\n"},
+		{"logfmt", LogfmtEncoder{}, record,
+			`time=2018-06-01T10:00:00.5Z level=error msg="upload failed" task=upload err="connection \"reset\"" elapsed=2s` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.enc.Encode(nil, tt.r)); got != tt.want {
+				t.Errorf("want:\n%q\ngot:\n%q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewWithEncoder(&buf, INFO, enc)
+	l.With("task", "upload").Warning("retrying", "attempt", 2)
+	l.Deprecated("old api")
+	l.Ok("done")
+	l.ColoredLine("line")
+	var labels []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid json line %q: %s", line, err)
+		}
+		labels = append(labels, rec["level"].(string))
+	}
+	if want := "[warning deprecated ok line]"; fmt.Sprint(labels) != want {
+		t.Errorf("want levels %s got %v", want, labels)
+	}
+	if !strings.Contains(buf.String(), `"msg":"retrying","task":"upload","attempt":2}`) {
+		t.Errorf("fields should be encoded got %s", buf.String())
+	}
+	if strings.Contains(buf.String(), "\x1b") {
+		t.Error("json output should not contain colors")
+	}
+
+	buf.Reset()
+	l.SetEncoder(nil)
+	l.TsDisabled()
+	l.Notice("text")
+	if want := " [ ⚠ notice    ] text\n"; buf.String() != want {
+		t.Errorf("want %q got %q", want, buf.String())
+	}
+	if _, err := NewEncoder("xml"); err == nil {
+		t.Error("unknown format should return error")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSinks(t *testing.T) {
+	var term, file, events bytes.Buffer
+	l := New(&term, NOTICE)
+	l.TsDisabled()
+	l.AddSink("file", Sink{W: &file, Level: DEBUG})
+	l.AddSink("events", Sink{W: &events, Level: WARNING, Encoder: JSONEncoder{}})
+	l.AddSink("colored", Sink{W: &events, Level: QUIET, Colors: true})
+	l.Warning("disk almost full")
+	l.Info("uploading")
+	l.Debug("chunk 1")
+	l.Line("done")
+
+	tests := []struct {
+		name string
+		buf  *bytes.Buffer
+		want []string
+	}{
+		{"term", &term, []string{"full", "done"}},
+		{"file", &file, []string{"full", "uploading", "1", "done"}},
+		{"events", &events, []string{`full"}`}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(tt.buf.String()), "\n") {
+			fields := strings.Fields(line)
+			got = append(got, fields[len(fields)-1])
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s want %v got %v", tt.name, tt.want, got)
+		}
+	}
+	if !strings.Contains(file.String(), time.Now().Format("2006-01-02")) {
+		t.Errorf("file sink should have timestamps got %q", file.String())
+	}
+
+	l.AddSink("colored", Sink{W: &events, Level: ERROR, Colors: true})
+	l.RemoveSink("events")
+	events.Reset()
+	l.Error("failed")
+	if !strings.Contains(events.String(), string(red)) {
+		t.Errorf("colored sink should colorize output got %q", events.String())
+	}
+	if fmt.Sprint(l.Sinks()) != "[file colored]" {
+		t.Errorf("want sinks [file colored] got %v", l.Sinks())
+	}
+
+	l.AddSink("broken", Sink{W: failingWriter{}, Level: DEBUG})
+	l.Notice("first")
+	l.Notice("second")
+	var serr *SinkError
+	if err := l.Err(); !errors.As(err, &serr) || serr.Sink != "broken" {
+		t.Fatalf("want sink error got %v", err)
+	}
+	var handled []string
+	l.SetErrorHandler(func(err error) {
+		handled = append(handled, fmt.Sprint(l.Sinks(), err))
+	})
+	l.Notice("third")
+	if fmt.Sprint(handled) != `[[file colored broken] log sink "broken": disk full]` {
+		t.Errorf("unexpected handled errors %v", handled)
+	}
+	if !strings.Contains(term.String(), "third") {
+		t.Error("sink error should not prevent writing to other outputs")
+	}
+
+	bar := New(failingWriter{}, NOTICE)
+	bar.NewProgress("upload", 2).Next()
+	if err := bar.Err(); err == nil || err.Error() != "disk full" {
+		t.Errorf("progress bar write error should be kept got %v", err)
+	}
+}
+
+func TestIndependentLoggers(t *testing.T) {
+	var verboseBuf, quietBuf bytes.Buffer
+	verbose := New(&verboseBuf, DEBUG)
+	verbose.Colors()
+	verbose.InitTerm()
+	quiet := New(&quietBuf, INFO)
+	quiet.Colors()
+	quiet.ColorsDisable()
+	quiet.TsDisabled()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			verbose.SetLogLevel(DEBUG)
+			verbose.SetPrimaryColor("blue")
+			verbose.Debug("verbose debug")
+			verbose.ColoredLine("verbose line")
+		}()
+		go func() {
+			defer wg.Done()
+			quiet.SetLogLevel(INFO)
+			quiet.Debug("quiet debug")
+			quiet.With("n", 1).Debug("quiet entry debug")
+			quiet.Warning("quiet warning")
+			quiet.ColoredLine("quiet line")
+		}()
+	}
+	wg.Wait()
+
+	if got := strings.Count(verboseBuf.String(), "verbose debug"); got != 4 {
+		t.Errorf("verbose logger want 4 debug messages got %d", got)
+	}
+	if !strings.Contains(verboseBuf.String(), string(blue)+"verbose line") {
+		t.Error("verbose logger should write colored lines")
+	}
+	if strings.Contains(quietBuf.String(), "debug") {
+		t.Errorf("debug level of other logger should not enable debug got:\n%s", quietBuf.String())
+	}
+	if strings.Contains(quietBuf.String(), "\x1b") {
+		t.Errorf("quiet logger should not write colors got %q", quietBuf.String())
+	}
+	if want := " [ ⚠ warning   ] quiet warning\n"; !strings.HasPrefix(quietBuf.String(), want) {
+		t.Errorf("disabling colors should not change alignment of other loggers want %q got %q",
+			want, quietBuf.String())
+	}
+
+	var stdBuf bytes.Buffer
+	l := New(&stdBuf, NOTICE)
+	verbose.SetLogLevel(DEBUG)
+	l.Debug("debug")
+	l.Debugf("debugf")
+	if stdBuf.Len() > 0 {
+		t.Errorf("new logger should not inherit debug level got %q", stdBuf.String())
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -29,12 +30,21 @@ type Logger struct {
 	inProgress   bool
 	exit         func(int)
 	msgBuf       []byte // for accumulating text to write out
-	started      timestamp
-	ts           *timestamp
 	primaryColor []byte
-	prfx         []byte
 	levelLocked  bool
 	term         *Term
+	lineW        io.Writer // output of lines, defaults to w
+	routes       []route   // outputs of levels sorted by level
+	enc          Encoder   // nil encodes with text encoder
+	sinks        []*namedSink
+	errHandler   func(error)
+	err          error // first write error when errHandler is not set
+}
+
+// route writes messages of level and more severe levels to w.
+type route struct {
+	level int
+	w     io.Writer
 }
 
 // Colors colirzes output
@@ -44,27 +54,38 @@ func (l *Logger) Colors() {
 	l.colors = true
 }
 
+// ColorsEnabled reports whether output is colorized
+func (l *Logger) ColorsEnabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.colors
+}
+
+// TermWidth returns width of the terminal initialized with InitTerm,
+// defaults to 80 if terminal is not initialized.
+func (l *Logger) TermWidth() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.term.Width()
+}
+
 // ColorsDisable disables output colors
 func (l *Logger) ColorsDisable() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.colors = false
-	padDef = 2
 }
 
 // Exit calls method set with SetExitFunc defaults to os.Exit
 func (l *Logger) Exit(code int) {
-	if l.exit != nil {
-		l.exit(code)
+	l.mu.Lock()
+	exit := l.exit
+	l.mu.Unlock()
+	if exit != nil {
+		exit(code)
 	}
 }
 
-// isValid checks whether Logger has valid Writer.
-// If not, it returns an bool false
-func (l *Logger) isValid() bool {
-	return l.w != nil
-}
-
 // NewProgress creates and returns new progress bar
 func (l *Logger) NewProgress(name string, steps int) *Progress {
 	return &Progress{name: name, steps: steps, log: l, started: time.Now()}
@@ -79,32 +100,96 @@ func (l *Logger) SetExitFunc(exit func(code int)) {
 
 // GetCurrentLevel returns current log level
 func (l *Logger) GetCurrentLevel() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return l.level
 }
 
 // SetLogLevel sets log level if loglevel is not locked by previous call
-// to .LockLevel
+// to .LockLevel. Debug messages are written only by loggers with DEBUG level.
 func (l *Logger) SetLogLevel(level int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if (level <= DEBUG || level >= QUIET) && !l.levelLocked {
-		l.mu.Lock()
-		defer l.mu.Unlock()
-		if level == DEBUG {
-			debug = true
-		}
 		l.level = level
 	}
 }
 
 // LockLevel locks log level so it can not be modified by SetLogLevel again
 func (l *Logger) LockLevel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.levelLocked = true
 }
 
-// SetOutput sets the output destination for the logger.
+// SetOutput sets the output destination for the logger. It removes outputs
+// set with SetLevelOutput and SetLineOutput, so all output is written to w.
 func (l *Logger) SetOutput(w io.Writer) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.w = w
+	l.lineW = nil
+	l.routes = nil
+}
+
+// Output returns the output destination of the logger.
+func (l *Logger) Output() io.Writer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w
+}
+
+// SetLevelOutput sets the output destination for messages of given level
+// and more severe levels e.g. SetLevelOutput(ERROR, os.Stderr) writes
+// errors, critical, alert, emergency, fatal and panic messages to os.Stderr.
+// When several outputs are set, message is written to output of the closest
+// level. Nil w removes output of the level.
+func (l *Logger) SetLevelOutput(level int, w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	routes := l.routes[:0:0]
+	for _, r := range l.routes {
+		if r.level != level {
+			routes = append(routes, r)
+		}
+	}
+	if w != nil {
+		routes = append(routes, route{level, w})
+		sort.Slice(routes, func(i, j int) bool { return routes[i].level < routes[j].level })
+	}
+	l.routes = routes
+}
+
+// SetLineOutput sets the output destination of Line, Linef, ColoredLine,
+// ColoredLinef and progress bars. Nil w writes lines to output set with
+// SetOutput.
+func (l *Logger) SetLineOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lineW = w
+}
+
+// LineOutput returns the output destination of lines.
+func (l *Logger) LineOutput() io.Writer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.output(lineLevel)
+}
+
+// SetEncoder sets encoder of log messages. Nil enc restores default
+// human readable format configured with Colors, Ts* and InitTerm methods.
+func (l *Logger) SetEncoder(enc Encoder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enc = enc
+}
+
+// Encoder returns encoder set with SetEncoder or nil when default human
+// readable format is used.
+func (l *Logger) Encoder() Encoder {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc
 }
 
 // TsDisabled disables timestamping log messages
@@ -134,8 +219,8 @@ func (l *Logger) TsTime() {
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Panic(v ...interface{}) {
 	s := fmt.Sprint(v...)
-	if l.level >= PANIC && l.isValid() {
-		l.write(s, l.prfx, sfxPanic[:], red)
+	if l.enabled(PANIC) {
+		l.write(PANIC, s, nil, sfxPanic[:], red)
 	}
 	panic(s)
 }
@@ -144,8 +229,8 @@ func (l *Logger) Panic(v ...interface{}) {
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Panicf(format string, v ...interface{}) {
 	s := fmt.Sprintf(format, v...)
-	if l.level >= PANIC && l.isValid() {
-		l.write(s, l.prfx, sfxPanic[:], red)
+	if l.enabled(PANIC) {
+		l.write(PANIC, s, nil, sfxPanic[:], red)
 	}
 	panic(s)
 }
@@ -155,8 +240,8 @@ func (l *Logger) Panicf(format string, v ...interface{}) {
 // Fatal is equivalent to l.Print() followed by a call to os.Exit(1).
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Fatal(v ...interface{}) {
-	if l.level >= FATAL && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxFatal[:], red)
+	if l.enabled(FATAL) {
+		l.write(FATAL, fmt.Sprint(v...), nil, sfxFatal[:], red)
 	}
 	l.Exit(1)
 }
@@ -164,8 +249,8 @@ func (l *Logger) Fatal(v ...interface{}) {
 // Fatalf is equivalent to l.Printf() followed by a call to os.Exit(1).
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Fatalf(format string, v ...interface{}) {
-	if l.level >= FATAL && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxFatal[:], red)
+	if l.enabled(FATAL) {
+		l.write(FATAL, fmt.Sprintf(format, v...), nil, sfxFatal[:], red)
 	}
 	l.Exit(1)
 }
@@ -175,16 +260,16 @@ func (l *Logger) Fatalf(format string, v ...interface{}) {
 // Emergency performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Emergency(v ...interface{}) {
-	if l.level >= EMERGENCY && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxEmergency[:], red)
+	if l.enabled(EMERGENCY) {
+		l.write(EMERGENCY, fmt.Sprint(v...), nil, sfxEmergency[:], red)
 	}
 }
 
 // Emergencyf performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Emergencyf(format string, v ...interface{}) {
-	if l.level >= EMERGENCY && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxEmergency[:], red)
+	if l.enabled(EMERGENCY) {
+		l.write(EMERGENCY, fmt.Sprintf(format, v...), nil, sfxEmergency[:], red)
 	}
 }
 
@@ -192,8 +277,8 @@ func (l *Logger) Emergencyf(format string, v ...interface{}) {
 // Arguments are handled in the manner of fmt.Println.
 // enables you to log and notice package users if any method is deprecated
 func (l *Logger) Deprecated(v ...interface{}) {
-	if l.level >= EMERGENCY && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxDeprecated[:], red)
+	if l.enabled(EMERGENCY) {
+		l.write(EMERGENCY, fmt.Sprint(v...), nil, sfxDeprecated[:], red)
 	}
 }
 
@@ -201,8 +286,8 @@ func (l *Logger) Deprecated(v ...interface{}) {
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 // enables you to log and notice package users if any method is deprecated
 func (l *Logger) Deprecatedf(format string, v ...interface{}) {
-	if l.level >= EMERGENCY && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxDeprecated[:], red)
+	if l.enabled(EMERGENCY) {
+		l.write(EMERGENCY, fmt.Sprintf(format, v...), nil, sfxDeprecated[:], red)
 	}
 }
 
@@ -211,8 +296,8 @@ func (l *Logger) Deprecatedf(format string, v ...interface{}) {
 // Alert performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Alert(v ...interface{}) {
-	if l.level >= ALERT && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxAlert[:], red)
+	if l.enabled(ALERT) {
+		l.write(ALERT, fmt.Sprint(v...), nil, sfxAlert[:], red)
 
 	}
 }
@@ -220,8 +305,8 @@ func (l *Logger) Alert(v ...interface{}) {
 // Alertf performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Alertf(format string, v ...interface{}) {
-	if l.level >= ALERT && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxAlert[:], red)
+	if l.enabled(ALERT) {
+		l.write(ALERT, fmt.Sprintf(format, v...), nil, sfxAlert[:], red)
 	}
 }
 
@@ -230,16 +315,16 @@ func (l *Logger) Alertf(format string, v ...interface{}) {
 // Critical performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Critical(v ...interface{}) {
-	if l.level >= CRITICAL && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxCritical[:], red)
+	if l.enabled(CRITICAL) {
+		l.write(CRITICAL, fmt.Sprint(v...), nil, sfxCritical[:], red)
 	}
 }
 
 // Criticalf performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Criticalf(format string, v ...interface{}) {
-	if l.level >= CRITICAL && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxCritical[:], red)
+	if l.enabled(CRITICAL) {
+		l.write(CRITICAL, fmt.Sprintf(format, v...), nil, sfxCritical[:], red)
 	}
 }
 
@@ -248,16 +333,16 @@ func (l *Logger) Criticalf(format string, v ...interface{}) {
 // Error performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Error(v ...interface{}) {
-	if l.level >= ERROR && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxError[:], red)
+	if l.enabled(ERROR) {
+		l.write(ERROR, fmt.Sprint(v...), nil, sfxError[:], red)
 	}
 }
 
 // Errorf performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Errorf(format string, v ...interface{}) {
-	if l.level >= ERROR && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxError[:], red)
+	if l.enabled(ERROR) {
+		l.write(ERROR, fmt.Sprintf(format, v...), nil, sfxError[:], red)
 	}
 }
 
@@ -266,16 +351,16 @@ func (l *Logger) Errorf(format string, v ...interface{}) {
 // Warning performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Warning(v ...interface{}) {
-	if l.level >= WARNING && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxWarning[:], yellow)
+	if l.enabled(WARNING) {
+		l.write(WARNING, fmt.Sprint(v...), nil, sfxWarning[:], yellow)
 	}
 }
 
 // Warningf performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Warningf(format string, v ...interface{}) {
-	if l.level >= WARNING && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxWarning[:], yellow)
+	if l.enabled(WARNING) {
+		l.write(WARNING, fmt.Sprintf(format, v...), nil, sfxWarning[:], yellow)
 	}
 }
 
@@ -284,32 +369,32 @@ func (l *Logger) Warningf(format string, v ...interface{}) {
 // Notice performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Notice(v ...interface{}) {
-	if l.level >= NOTICE && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxNotice[:], cyan)
+	if l.enabled(NOTICE) {
+		l.write(NOTICE, fmt.Sprint(v...), nil, sfxNotice[:], cyan)
 	}
 }
 
 // Noticef performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Noticef(format string, v ...interface{}) {
-	if l.level >= NOTICE && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxNotice[:], cyan)
+	if l.enabled(NOTICE) {
+		l.write(NOTICE, fmt.Sprintf(format, v...), nil, sfxNotice[:], cyan)
 	}
 }
 
 // Line performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Line(v ...interface{}) {
-	if l.level >= LINE && l.isValid() {
-		l.write(fmt.Sprint(v...), nil, nil, nil)
+	if l.enabled(LINE) {
+		l.write(lineLevel, fmt.Sprint(v...), nil, nil, nil)
 	}
 }
 
 // Linef performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Linef(format string, v ...interface{}) {
-	if l.level >= LINE && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), nil, nil, nil)
+	if l.enabled(LINE) {
+		l.write(lineLevel, fmt.Sprintf(format, v...), nil, nil, nil)
 	}
 }
 
@@ -318,16 +403,16 @@ func (l *Logger) Linef(format string, v ...interface{}) {
 // Info performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Info(v ...interface{}) {
-	if l.level >= INFO && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxInfo[:], cyan)
+	if l.enabled(INFO) {
+		l.write(INFO, fmt.Sprint(v...), nil, sfxInfo[:], cyan)
 	}
 }
 
 // Infof performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Infof(format string, v ...interface{}) {
-	if l.level >= INFO && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxInfo[:], cyan)
+	if l.enabled(INFO) {
+		l.write(INFO, fmt.Sprintf(format, v...), nil, sfxInfo[:], cyan)
 	}
 }
 
@@ -336,16 +421,16 @@ func (l *Logger) Infof(format string, v ...interface{}) {
 // Ok performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Ok(v ...interface{}) {
-	if l.level >= OK && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxOk[:], green)
+	if l.enabled(OK) {
+		l.write(OK, fmt.Sprint(v...), nil, sfxOk[:], green)
 	}
 }
 
 // Okf performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Okf(format string, v ...interface{}) {
-	if l.level >= OK && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxOk[:], green)
+	if l.enabled(OK) {
+		l.write(OK, fmt.Sprintf(format, v...), nil, sfxOk[:], green)
 	}
 }
 
@@ -354,21 +439,23 @@ func (l *Logger) Okf(format string, v ...interface{}) {
 // Debug performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Debug(v ...interface{}) {
-	if debug && l.isValid() {
-		l.write(fmt.Sprint(v...), l.prfx, sfxDebug[:], white)
+	if l.enabled(DEBUG) {
+		l.write(DEBUG, fmt.Sprint(v...), nil, sfxDebug[:], white)
 	}
 }
 
 // Debugf performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	if debug && l.isValid() {
-		l.write(fmt.Sprintf(format, v...), l.prfx, sfxDebug[:], white)
+	if l.enabled(DEBUG) {
+		l.write(DEBUG, fmt.Sprintf(format, v...), nil, sfxDebug[:], white)
 	}
 }
 
 // SetPrimaryColor sets color for ColoredLine and ColoredLinef
 func (l *Logger) SetPrimaryColor(color string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	switch color {
 	case "black":
 		l.primaryColor = black
@@ -389,16 +476,18 @@ func (l *Logger) SetPrimaryColor(color string) {
 	}
 }
 
+func (l *Logger) getPrimaryColor() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.primaryColor
+}
+
 // ColoredLine performs write to the loggers attached io.Writer.
 // Arguments are handled in the manner of fmt.Println.
 // line is colored with color set by SetPrimaryColor
 func (l *Logger) ColoredLine(v ...interface{}) {
-	if l.isValid() && l.level >= LINE {
-		msg := fmt.Sprint(v...)
-		if l.colors {
-			msg = string(l.primaryColor) + msg + string(reset)
-		}
-		l.write(msg, nil, nil, nil)
+	if l.enabled(LINE) {
+		l.write(lineLevel, fmt.Sprint(v...), nil, nil, l.getPrimaryColor())
 	}
 }
 
@@ -406,18 +495,16 @@ func (l *Logger) ColoredLine(v ...interface{}) {
 // Arguments are handled in the manner of fmt.Printf followed by \n.
 // line is colored with color set by SetPrimaryColor
 func (l *Logger) ColoredLinef(format string, v ...interface{}) {
-	if l.isValid() && l.level >= LINE {
-		msg := fmt.Sprintf(format, v...)
-		if l.colors {
-			msg = string(l.primaryColor) + msg + string(reset)
-		}
-		l.write(msg, nil, nil, nil)
+	if l.enabled(LINE) {
+		l.write(lineLevel, fmt.Sprintf(format, v...), nil, nil, l.getPrimaryColor())
 	}
 }
 
 // InitTerm puts current terminal into raw mode and enables logger to use
 // current terminal
 func (l *Logger) InitTerm() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if l.term != nil {
 		return
 	}
@@ -437,13 +524,16 @@ func (l *Logger) printProgress(name string, pct float32, started time.Time) {
 	isDone := int(pct) == 100
 	if isDone {
 		elapsed := time.Now().Sub(started)
-		l.write(fmt.Sprintf("%s [100%% elapsed %s]", name, elapsed.String()), nil, sfxOk[:], green)
+		l.write(lineLevel, fmt.Sprintf("%s [100%% elapsed %s]", name, elapsed.String()), nil, sfxOk[:], green)
 		return
 	}
 
 	l.mu.Lock()
-	defer l.mu.Unlock()
-
+	// progress bar is drawn only in human readable output
+	if _, ok := l.encoder().(TextEncoder); !ok || l.w == nil || !accepts(l.level, lineLevel) {
+		l.mu.Unlock()
+		return
+	}
 	l.inProgress = true
 	l.msgBuf = l.msgBuf[:0]
 	l.msgBuf = append(l.msgBuf, _cr)
@@ -466,53 +556,91 @@ func (l *Logger) printProgress(name string, pct float32, started time.Time) {
 		l.msgBuf = append(l.msgBuf, pad...)
 	}
 	l.msgBuf = append(l.msgBuf, suffix...)
-	_, err := l.w.Write(l.msgBuf)
+	_, err := l.output(lineLevel).Write(l.msgBuf)
+	l.mu.Unlock()
 	if err != nil {
-		fmt.Println(err)
+		l.handleErrors([]error{err})
 	}
 }
 
-// write writes the output for a logging event. The string s contains
-func (l *Logger) write(s string, prfx []byte, suffix []byte, color []byte) error {
-
-	if l.colors && color != nil && suffix != nil {
-		suffix = append(color, suffix...)
-		suffix = append(suffix, reset...)
-		padDef = 11
+// write encodes logging event and writes it to the output of the level and
+// sinks accepting the level. Lines are written with level lineLevel.
+func (l *Logger) write(level int, msg string, fields []Field, sfx []byte, color []byte) error {
+	r := Record{
+		Time:   time.Now(),
+		Level:  level,
+		Msg:    msg,
+		Fields: fields,
+		sfx:    sfx,
+		color:  color,
+	}
+	if sfx != nil {
+		r.Label = levelLabel(sfx)
 	}
+	if level == lineLevel {
+		r.Level = LINE
+		r.Line = true
+	}
+	var errs []error
 	l.mu.Lock()
-	defer l.mu.Unlock()
+	if l.w != nil && accepts(l.level, level) {
+		l.msgBuf = l.msgBuf[:0]
+		if l.inProgress {
+			// Delete current progressbar
+			l.msgBuf = append(l.msgBuf, _cr)
+		}
+		l.msgBuf = l.encoder().Encode(l.msgBuf, &r)
+		if _, err := l.output(level).Write(l.msgBuf); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, s := range l.sinks {
+		if !accepts(s.Level, level) {
+			continue
+		}
+		s.buf = s.Encoder.Encode(s.buf[:0], &r)
+		if _, err := s.W.Write(s.buf); err != nil {
+			errs = append(errs, &SinkError{Sink: s.name, Err: err})
+		}
+	}
+	l.mu.Unlock()
+	l.handleErrors(errs)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
 
-	if l.wt > t0 && prfx != nil {
-		ts := l.ts.now(l.wt)
-		prfx = append(ts, prfx...)
+// encoder returns encoder of the logger. Caller must hold l.mu.
+func (l *Logger) encoder() Encoder {
+	if l.enc != nil {
+		return l.enc
 	}
-	l.msgBuf = l.msgBuf[:0]
-	if l.inProgress {
-		// Delete current progressbar
-		l.msgBuf = append(l.msgBuf, _cr)
+	enc := TextEncoder{Colors: l.colors}
+	switch l.wt {
+	case t1:
+		enc.Timestamp = tsLayoutStandard
+	case t2:
+		enc.Timestamp = tsLayoutTime
 	}
-	if !l.aligned && suffix != nil {
-		prfx = append(prfx, suffix...)
+	if l.aligned {
+		enc.Width = l.term.Width()
 	}
-	l.msgBuf = append(l.msgBuf, prfx...)
-	l.msgBuf = append(l.msgBuf, 32)
-	l.msgBuf = append(l.msgBuf, s...)
-
-	if l.aligned && suffix != nil {
-		bodyLen := len(l.msgBuf)
-		sfxLen := len(suffix)
-		termW := l.term.Width()
-		padLen := termW - bodyLen - sfxLen
-		r := padLen + padDef
-		if r > 0 {
-			pad := bytes.Repeat([]byte{32}, r)
-			l.msgBuf = append(l.msgBuf, pad...)
-		}
+	return enc
+}
 
-		l.msgBuf = append(l.msgBuf, suffix...)
+// output returns writer for messages of given level. Caller must hold l.mu.
+func (l *Logger) output(level int) io.Writer {
+	if level == lineLevel {
+		if l.lineW != nil {
+			return l.lineW
+		}
+		return l.w
+	}
+	for _, r := range l.routes {
+		if level <= r.level {
+			return r.w
+		}
 	}
-	l.msgBuf = append(l.msgBuf, _lf)
-	_, err := l.w.Write(l.msgBuf)
-	return err
+	return l.w
 }
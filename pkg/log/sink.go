@@ -0,0 +1,147 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"fmt"
+	"io"
+)
+
+const fmtErrSink = "log sink %q: %s"
+
+// Sink is additional destination of log messages with its own level and
+// encoder e.g. file receiving debug messages while terminal shows notices.
+type Sink struct {
+	W       io.Writer
+	Level   int     // most verbose level written to the sink, lines need LINE
+	Encoder Encoder // defaults to TextEncoder with standard timestamp
+	Colors  bool    // colorize output of default encoder
+}
+
+// namedSink is sink added to the logger.
+type namedSink struct {
+	name string
+	Sink
+	buf []byte
+}
+
+// AddSink adds sink which receives log messages in addition to the output
+// of the logger. Sink with same name is replaced. Errors writing to the
+// sink are passed to the error handler of the logger.
+func (l *Logger) AddSink(name string, s Sink) {
+	if s.Encoder == nil {
+		s.Encoder = TextEncoder{Colors: s.Colors, Timestamp: tsLayoutStandard}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.sinks {
+		if l.sinks[i].name == name {
+			l.sinks[i] = &namedSink{name: name, Sink: s}
+			return
+		}
+	}
+	l.sinks = append(l.sinks, &namedSink{name: name, Sink: s})
+}
+
+// RemoveSink removes sink added with AddSink.
+func (l *Logger) RemoveSink(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sinks := l.sinks[:0:0]
+	for _, s := range l.sinks {
+		if s.name != name {
+			sinks = append(sinks, s)
+		}
+	}
+	l.sinks = sinks
+}
+
+// Sinks returns names of sinks added with AddSink.
+func (l *Logger) Sinks() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	names := make([]string, len(l.sinks))
+	for i, s := range l.sinks {
+		names[i] = s.name
+	}
+	return names
+}
+
+// SetErrorHandler sets function called with errors writing to the output
+// or sinks of the logger. Errors of sinks are wrapped in *SinkError.
+// Without handler the first error is kept and returned by Err.
+func (l *Logger) SetErrorHandler(fn func(err error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errHandler = fn
+}
+
+// Err returns the first error writing to the output or sinks of the logger
+// when error handler is not set.
+func (l *Logger) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// SinkError is error writing to the sink.
+type SinkError struct {
+	Sink string
+	Err  error
+}
+
+// Error implements error interface.
+func (e *SinkError) Error() string {
+	return fmt.Sprintf(fmtErrSink, e.Sink, e.Err)
+}
+
+// Unwrap returns error returned by writer of the sink.
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
+
+// handleErrors passes write errors to the error handler. It must be
+// called without holding l.mu so that handler can use the logger.
+func (l *Logger) handleErrors(errs []error) {
+	if len(errs) == 0 {
+		return
+	}
+	l.mu.Lock()
+	fn := l.errHandler
+	if fn == nil && l.err == nil {
+		l.err = errs[0]
+	}
+	l.mu.Unlock()
+	if fn != nil {
+		for _, err := range errs {
+			fn(err)
+		}
+	}
+}
+
+// accepts reports whether message of level passes level max. Lines are
+// accepted on LINE level.
+func accepts(max, level int) bool {
+	if level == lineLevel {
+		level = LINE
+	}
+	return level <= max
+}
+
+// enabled reports whether message of level is written to the output or
+// any of the sinks.
+func (l *Logger) enabled(level int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w != nil && accepts(l.level, level) {
+		return true
+	}
+	for _, s := range l.sinks {
+		if accepts(s.Level, level) {
+			return true
+		}
+	}
+	return false
+}
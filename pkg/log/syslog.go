@@ -0,0 +1,270 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+const (
+	// FacilityUser is syslog facility of user-level messages.
+	FacilityUser = 1
+	// FacilityLocal0 is first of syslog facilities local0 - local7.
+	FacilityLocal0 = 16
+
+	// syslogFieldsID is SD-ID of structured data element carrying fields,
+	// 32473 is private enterprise number reserved for documentation.
+	syslogFieldsID   = "fields@32473"
+	syslogTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+	fmtErrSyslogDial = "failed to connect to syslog %s: %s"
+)
+
+// syslogSockets are paths of local syslog sockets, journald listens on
+// /dev/log as well.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogEncoder encodes records as RFC 5424 syslog messages e.g.
+//
+//	<14>1 2018-06-01T10:00:00.500000Z host howi 42 - [fields@32473 task="upload"] done
+//
+// Levels are mapped directly to syslog severities, PANIC and FATAL are
+// written as emergency and lines as notice.
+type SyslogEncoder struct {
+	Facility int    // defaults to FacilityUser
+	Hostname string // NILVALUE "-" when empty
+	AppName  string
+	ProcID   string
+}
+
+// NewSyslogEncoder returns syslog encoder with hostname and process id of
+// current process.
+func NewSyslogEncoder(appName string) SyslogEncoder {
+	hostname, _ := os.Hostname()
+	return SyslogEncoder{
+		Facility: FacilityUser,
+		Hostname: hostname,
+		AppName:  appName,
+		ProcID:   strconv.Itoa(os.Getpid()),
+	}
+}
+
+// Encode implements Encoder.
+func (e SyslogEncoder) Encode(buf []byte, r *Record) []byte {
+	facility := e.Facility
+	if facility == 0 {
+		facility = FacilityUser
+	}
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(facility*8+syslogSeverity(r.Level)), 10)
+	buf = append(buf, ">1 "...)
+	if r.Time.IsZero() {
+		buf = append(buf, '-')
+	} else {
+		buf = r.Time.AppendFormat(buf, syslogTimeLayout)
+	}
+	buf = append(buf, ' ')
+	buf = appendSyslogName(buf, e.Hostname, 255)
+	buf = append(buf, ' ')
+	buf = appendSyslogName(buf, e.AppName, 48)
+	buf = append(buf, ' ')
+	buf = appendSyslogName(buf, e.ProcID, 128)
+	buf = append(buf, " - "...) // MSGID
+	if len(r.Fields) == 0 {
+		buf = append(buf, '-')
+	} else {
+		buf = append(buf, "["+syslogFieldsID...)
+		for _, f := range r.Fields {
+			buf = append(buf, ' ')
+			buf = appendSyslogName(buf, f.Key, 32)
+			buf = append(buf, `="`...)
+			buf = appendSyslogParam(buf, fmt.Sprint(f.Value))
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ']')
+	}
+	if r.Msg != "" {
+		buf = append(buf, ' ')
+		buf = appendSyslogMsg(buf, r.Msg)
+	}
+	return append(buf, _lf)
+}
+
+// syslogSeverity maps log level to syslog severity.
+func syslogSeverity(level int) int {
+	switch {
+	case level <= EMERGENCY:
+		return 0
+	case level >= DEBUG:
+		return 7
+	}
+	return level - EMERGENCY
+}
+
+// appendSyslogName appends header field or parameter name replacing
+// characters not allowed by RFC 5424 with '_'. Empty value is written as
+// NILVALUE "-".
+func appendSyslogName(buf []byte, s string, max int) []byte {
+	if s == "" {
+		return append(buf, '-')
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendSyslogParam appends structured data parameter value escaping '"',
+// '\' and ']' and replacing control characters with space.
+func appendSyslogParam(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\' || c == ']':
+			buf = append(buf, '\\', c)
+		case c < 32 || c == 127:
+			buf = append(buf, ' ')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// appendSyslogMsg appends message replacing control characters with space
+// so that multiline message does not break newline framing of the stream.
+func appendSyslogMsg(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 32 || c == 127 {
+			buf = append(buf, ' ')
+		} else {
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// Syslog writes messages encoded with SyslogEncoder to syslog daemon. It is
+// meant to be used as writer of the Sink e.g.
+//
+//	s, err := log.DialSyslog("", "")
+//	l.AddSink("syslog", log.Sink{W: s, Level: log.INFO, Encoder: log.NewSyslogEncoder("howi")})
+//
+// Messages written over TCP are framed with octet counting as described in
+// RFC 6587 and over local Unix stream socket terminated with newline.
+// Syslog reconnects once when write fails and is safe for concurrent use.
+type Syslog struct {
+	mu      sync.Mutex
+	network string
+	addr    string
+	conn    net.Conn
+	framing byte // framing of messages, 0 for datagrams
+}
+
+// Framing of syslog messages written over stream connections.
+const (
+	frameOctets  = 'o' // octet counting used over TCP
+	frameNewline = 'n' // trailing newline used by local Unix stream sockets
+)
+
+// DialSyslog connects to syslog at addr over network "udp", "tcp", "unix"
+// or "unixgram". Empty network connects to local syslog socket.
+func DialSyslog(network, addr string) (*Syslog, error) {
+	s := &Syslog{network: network, addr: addr}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write writes single syslog message p, trailing newline is removed.
+func (s *Syslog) Write(p []byte) (int, error) {
+	msg := bytes.TrimSuffix(p, []byte{_lf})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		if err := s.write(msg); err == nil {
+			return len(p), nil
+		}
+		s.conn.Close()
+	}
+	if err := s.connect(); err != nil {
+		return 0, err
+	}
+	if err := s.write(msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes connection to syslog.
+func (s *Syslog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *Syslog) write(msg []byte) error {
+	switch s.framing {
+	case frameOctets:
+		msg = append(strconv.AppendInt(nil, int64(len(msg)), 10), append([]byte{' '}, msg...)...)
+	case frameNewline:
+		msg = append(msg[:len(msg):len(msg)], _lf)
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// connect connects to syslog. Caller must hold s.mu unless s is not shared.
+func (s *Syslog) connect() error {
+	s.conn = nil
+	if s.network != "" {
+		conn, err := net.DialTimeout(s.network, s.addr, 5*time.Second)
+		if err != nil {
+			return errors.Newf(fmtErrSyslogDial, s.addr, err)
+		}
+		s.conn, s.framing = conn, 0
+		switch s.network {
+		case "tcp", "tcp4", "tcp6":
+			s.framing = frameOctets
+		case "unix":
+			s.framing = frameNewline
+		}
+		return nil
+	}
+	var err error
+	for _, path := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			var conn net.Conn
+			if conn, err = net.Dial(network, path); err == nil {
+				s.conn, s.framing = conn, 0
+				if network == "unix" {
+					s.framing = frameNewline
+				}
+				return nil
+			}
+		}
+	}
+	return errors.Newf(fmtErrSyslogDial, "local socket", err)
+}
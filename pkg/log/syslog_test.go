@@ -0,0 +1,154 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogEncoder(t *testing.T) {
+	ts := time.Date(2018, 6, 1, 10, 0, 0, 500000000, time.UTC)
+	enc := SyslogEncoder{Hostname: "build host", AppName: "howi", ProcID: "42"}
+	tests := []struct {
+		name string
+		r    *Record
+		want string
+	}{
+		{"fields", &Record{Time: ts, Level: INFO, Msg: "done", Fields: Fields("task", "upload", "path", `C:\tmp "a]"`)},
+			`<14>1 2018-06-01T10:00:00.500000Z build_host howi 42 - [fields@32473 task="upload" path="C:\\tmp \"a\]\""] done`},
+		{"emergency", &Record{Time: ts, Level: PANIC, Msg: "panic"},
+			"<8>1 2018-06-01T10:00:00.500000Z build_host howi 42 - - panic"},
+		{"error", &Record{Level: ERROR, Msg: "failed"}, "<11>1 - build_host howi 42 - - failed"},
+		{"notice", &Record{Level: NOTICE, Msg: "deploying"}, "<13>1 - build_host howi 42 - - deploying"},
+		{"debug", &Record{Level: DEBUG}, "<15>1 - build_host howi 42 - -"},
+		{"multiline", &Record{Level: NOTICE, Msg: "first\nsecond\r\tthird", Fields: Fields("err", "a\nb")},
+			`<13>1 - build_host howi 42 - [fields@32473 err="a b"] first second  third`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(enc.Encode(nil, tt.r)); got != tt.want+"\n" {
+				t.Errorf("want:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+	local := SyslogEncoder{Facility: FacilityLocal0 + 1}
+	if got := string(local.Encode(nil, &Record{Level: WARNING})); !strings.HasPrefix(got, "<140>1 - - - -") {
+		t.Errorf("local1 warning want priority 140 got %q", got)
+	}
+}
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	s, err := DialSyslog("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	l := New(nil, QUIET)
+	l.AddSink("syslog", Sink{W: s, Level: INFO, Encoder: NewSyslogEncoder("howi")})
+	l.Error("first")
+	l.With("task", "upload").Info("second")
+	l.Debug("ignored")
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	want := []string{
+		fmt.Sprintf(" howi %d - - first", os.Getpid()),
+		fmt.Sprintf(` howi %d - [fields@32473 task="upload"] second`, os.Getpid()),
+	}
+	buf := make([]byte, 2048)
+	for i, suffix := range want {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := string(buf[:n])
+		if !strings.HasPrefix(msg, []string{"<11>1 ", "<14>1 "}[i]) || !strings.HasSuffix(msg, suffix) {
+			t.Errorf("unexpected message %q", msg)
+		}
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	s, err := DialSyslog("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// octet counting keeps newlines written by other encoders intact
+	for _, msg := range []string{"<13>1 - - howi - - - first\n", "<13>1 - - howi - - - second\nline\n"} {
+		if _, err := s.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"<13>1 - - howi - - - first", "<13>1 - - howi - - - second\nline"} {
+		var n int
+		if _, err := fmt.Fscanf(r, "%d ", &n); err != nil {
+			t.Fatal(err)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatal(err)
+		}
+		if string(msg) != want {
+			t.Errorf("want %q got %q", want, msg)
+		}
+	}
+}
+
+func TestSyslogUnixgram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "howi-syslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip("unixgram is not supported: ", err)
+	}
+	defer conn.Close()
+	defer func(sockets []string) { syslogSockets = sockets }(syslogSockets)
+	syslogSockets = []string{filepath.Join(dir, "missing"), path}
+	s, err := DialSyslog("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.Write(SyslogEncoder{AppName: "howi"}.Encode(nil, &Record{Level: ALERT, Msg: "local"}))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 512)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "<9>1 - - howi - - - local" {
+		t.Errorf("unexpected message %q", got)
+	}
+}
@@ -22,10 +22,10 @@ type Term struct {
 	state *terminal.State
 }
 
-// Width returns cuurent line with
+// Width returns cuurent line with, defaults to 80 if terminal size is unknown
 func (t *Term) Width() (w int) {
 	w = 80
-	if t != nil {
+	if t != nil && t.size.w > 0 {
 		w = t.size.w
 	}
 	return w
@@ -0,0 +1,72 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"time"
+
+	"github.com/blang/semver"
+)
+
+// About is machine readable summary of the project metadata e.g. printed
+// by about command of the application.
+type About struct {
+	Name         string            `json:"name"`
+	Namespace    string            `json:"namespace,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Version      semver.Version    `json:"version"`
+	BuildDate    *time.Time        `json:"builddate,omitempty"`
+	Commit       string            `json:"commit,omitempty"`
+	Dirty        bool              `json:"dirty,omitempty"`
+	CommitDate   *time.Time        `json:"commitdate,omitempty"`
+	GoVersion    string            `json:"goversion,omitempty"`
+	License      string            `json:"license,omitempty"`
+	Homepage     string            `json:"homepage,omitempty"`
+	Repository   string            `json:"repository,omitempty"`
+	Bugs         *Bugs             `json:"bugs,omitempty"`
+	Keywords     []string          `json:"keywords,omitempty"`
+	Author       string            `json:"author,omitempty"`
+	Copyright    *Copyright        `json:"copyright,omitempty"`
+	Contributors []string          `json:"contributors,omitempty"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// About returns summary of the project metadata. Fields which are not set
+// are left empty so that they are omitted when About is encoded.
+func (prj *Project) About() About {
+	about := About{
+		Name:         prj.Name,
+		Namespace:    prj.Namespace,
+		Title:        prj.Title,
+		Description:  prj.Description,
+		Version:      prj.Version,
+		Commit:       prj.Commit,
+		Dirty:        prj.Dirty,
+		GoVersion:    prj.GoVersion,
+		License:      prj.License,
+		Homepage:     prj.Homepage,
+		Repository:   prj.Repository,
+		Keywords:     prj.Keywords,
+		Author:       prj.Author.String(),
+		Dependencies: prj.Dependencies,
+	}
+	if !prj.BuildDate.IsZero() {
+		about.BuildDate = &prj.BuildDate
+	}
+	if !prj.CommitDate.IsZero() {
+		about.CommitDate = &prj.CommitDate
+	}
+	if prj.Bugs.URL != "" || prj.Bugs.Email.String() != "" {
+		about.Bugs = &prj.Bugs
+	}
+	if prj.Copyright.Since > 0 || prj.Copyright.By != "" {
+		about.Copyright = &prj.Copyright
+	}
+	for _, contributor := range prj.Contributors {
+		about.Contributors = append(about.Contributors, contributor.String())
+	}
+	return about
+}
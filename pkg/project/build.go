@@ -0,0 +1,131 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+// Build metadata which can be injected at link time e.g.
+//
+//	go build -ldflags "\
+//	  -X github.com/digaverse/howi/pkg/project.version=1.2.3 \
+//	  -X github.com/digaverse/howi/pkg/project.buildDate=2018-03-06T03:06:34+02:00 \
+//	  -X github.com/digaverse/howi/pkg/project.commit=$(git rev-parse HEAD) \
+//	  -X github.com/digaverse/howi/pkg/project.dirty=true"
+//
+// Values set with ldflags take precedence over project configuration
+// and over information embedded by the go toolchain.
+var (
+	version   string
+	buildDate string
+	commit    string
+	dirty     string
+)
+
+// BuildInfo describes the build of the running binary.
+type BuildInfo struct {
+	Name       string         `json:"name"`
+	Version    semver.Version `json:"version"`
+	Commit     string         `json:"commit,omitempty"`
+	Dirty      bool           `json:"dirty"`
+	CommitDate *time.Time     `json:"commitdate,omitempty"`
+	BuildDate  *time.Time     `json:"builddate,omitempty"`
+	GoVersion  string         `json:"goversion,omitempty"`
+}
+
+// BuildInfo returns build metadata of the project.
+func (prj *Project) BuildInfo() BuildInfo {
+	info := BuildInfo{
+		Name:      prj.Name,
+		Version:   prj.Version,
+		Commit:    prj.Commit,
+		Dirty:     prj.Dirty,
+		GoVersion: prj.GoVersion,
+	}
+	if !prj.CommitDate.IsZero() {
+		info.CommitDate = &prj.CommitDate
+	}
+	if !prj.BuildDate.IsZero() {
+		info.BuildDate = &prj.BuildDate
+	}
+	return info
+}
+
+// loadBuildInfo populates build metadata from the information embedded by
+// the go toolchain and from variables set with -ldflags -X.
+func (prj *Project) loadBuildInfo() {
+	prj.GoVersion = runtime.Version()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		prj.loadVCSInfo(info)
+	}
+	prj.loadLinkerFlags()
+}
+
+// loadVCSInfo fills only the fields which are not set by project configuration.
+// Revision, modified flag and commit time are taken together and only when
+// commit is not configured. Commit time is stored as CommitDate since it is
+// not the time when the binary was built.
+func (prj *Project) loadVCSInfo(info *debug.BuildInfo) {
+	if info.GoVersion != "" {
+		prj.GoVersion = info.GoVersion
+	}
+	if prj.Version.Equals(semver.Version{}) && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		if v, err := semver.ParseTolerant(info.Main.Version); err == nil {
+			prj.Version = v
+		}
+	}
+	if prj.Commit != "" {
+		return
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			prj.Commit = s.Value
+		case "vcs.modified":
+			prj.Dirty = s.Value == "true"
+		case "vcs.time":
+			if t, err := time.Parse(time.RFC3339, s.Value); err == nil {
+				prj.CommitDate = t
+			}
+		}
+	}
+}
+
+// loadLinkerFlags overrides build metadata with values set by -ldflags -X.
+func (prj *Project) loadLinkerFlags() {
+	if version != "" {
+		v, err := semver.ParseTolerant(version)
+		if err != nil {
+			prj.errors.Appendf("invalid build version %q: %s", version, err)
+		} else {
+			prj.Version = v
+		}
+	}
+	if buildDate != "" {
+		t, err := time.Parse(time.RFC3339, buildDate)
+		if err != nil {
+			prj.errors.Appendf("invalid build date %q: %s", buildDate, err)
+		} else {
+			prj.BuildDate = t
+		}
+	}
+	if commit != "" {
+		prj.Commit = commit
+	}
+	if dirty != "" {
+		d, err := strconv.ParseBool(dirty)
+		if err != nil {
+			prj.errors.Appendf("invalid build dirty flag %q: %s", dirty, err)
+		} else {
+			prj.Dirty = d
+		}
+	}
+}
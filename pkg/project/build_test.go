@@ -0,0 +1,139 @@
+// Copyright 2018 DIGAVERSE. All rights reserved.
+// Use of this source code is governed by a The Apache-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/digaverse/howi/pkg/errors"
+)
+
+func TestLoadLinkerFlags(t *testing.T) {
+	defer func(v, d, c, dt string) {
+		version, buildDate, commit, dirty = v, d, c, dt
+	}(version, buildDate, commit, dirty)
+
+	tests := []struct {
+		name        string
+		version     string
+		buildDate   string
+		commit      string
+		dirty       string
+		wantVersion string
+		wantDate    string
+		wantCommit  string
+		wantDirty   bool
+		wantErrs    int
+	}{
+		{"unset", "", "", "", "", "0.1.0", "", "config", false, 0},
+		{"all", "v1.2.3", "2018-03-06T03:06:34+02:00", "1a2b3c", "true",
+			"1.2.3", "2018-03-06T03:06:34+02:00", "1a2b3c", true, 0},
+		{"utc date", "", "2018-03-06T01:06:34Z", "", "false", "0.1.0", "2018-03-06T01:06:34Z", "config", false, 0},
+		{"dirty 1", "", "", "", "1", "0.1.0", "", "config", true, 0},
+		{"invalid date", "", "2018-03-06", "", "", "0.1.0", "", "config", false, 1},
+		{"invalid version", "one", "", "", "", "0.1.0", "", "config", false, 1},
+		{"invalid dirty", "", "", "", "yes", "0.1.0", "", "config", false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, buildDate, commit, dirty = tt.version, tt.buildDate, tt.commit, tt.dirty
+			prj := &Project{
+				errors:  errors.NewMultiError(),
+				Version: semver.MustParse("0.1.0"),
+				Commit:  "config",
+			}
+			prj.loadLinkerFlags()
+			if got := prj.Version.String(); got != tt.wantVersion {
+				t.Errorf("version want %s got %s", tt.wantVersion, got)
+			}
+			var wantDate time.Time
+			if tt.wantDate != "" {
+				wantDate, _ = time.Parse(time.RFC3339, tt.wantDate)
+			}
+			if !prj.BuildDate.Equal(wantDate) {
+				t.Errorf("build date want %s got %s", wantDate, prj.BuildDate)
+			}
+			if prj.Commit != tt.wantCommit {
+				t.Errorf("commit want %q got %q", tt.wantCommit, prj.Commit)
+			}
+			if prj.Dirty != tt.wantDirty {
+				t.Errorf("dirty want %t got %t", tt.wantDirty, prj.Dirty)
+			}
+			if prj.errors.Len() != tt.wantErrs {
+				t.Errorf("want %d errors got %d: %v", tt.wantErrs, prj.errors.Len(), prj.errors.AsError())
+			}
+		})
+	}
+}
+
+func TestLoadVCSInfo(t *testing.T) {
+	configured := time.Date(2018, 3, 6, 1, 6, 34, 0, time.UTC)
+	committed := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	settings := []debug.BuildSetting{
+		{Key: "vcs", Value: "git"},
+		{Key: "vcs.revision", Value: "1a2b3c"},
+		{Key: "vcs.time", Value: "2018-06-01T10:00:00Z"},
+		{Key: "vcs.modified", Value: "true"},
+	}
+	tests := []struct {
+		name           string
+		version        string
+		buildDate      time.Time
+		commit         string
+		info           debug.BuildInfo
+		wantVersion    string
+		wantDate       time.Time
+		wantCommit     string
+		wantCommitDate time.Time
+		wantDirty      bool
+		wantGo         string
+	}{
+		{"vcs settings", "", time.Time{}, "",
+			debug.BuildInfo{GoVersion: "go1.21.0", Main: debug.Module{Version: "(devel)"}, Settings: settings},
+			"0.0.0", time.Time{}, "1a2b3c", committed, true, "go1.21.0"},
+		{"module version", "", time.Time{}, "",
+			debug.BuildInfo{Main: debug.Module{Version: "v1.2.3"}},
+			"1.2.3", time.Time{}, "", time.Time{}, false, "go1.20"},
+		{"configured", "0.1.0", configured, "config",
+			debug.BuildInfo{Main: debug.Module{Version: "v1.2.3"}, Settings: settings},
+			"0.1.0", configured, "config", time.Time{}, false, "go1.20"},
+		{"configured build date", "", configured, "",
+			debug.BuildInfo{Settings: settings},
+			"0.0.0", configured, "1a2b3c", committed, true, "go1.20"},
+		{"invalid vcs time", "", time.Time{}, "",
+			debug.BuildInfo{Settings: []debug.BuildSetting{{Key: "vcs.time", Value: "yesterday"}}},
+			"0.0.0", time.Time{}, "", time.Time{}, false, "go1.20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prj := &Project{BuildDate: tt.buildDate, Commit: tt.commit, GoVersion: "go1.20"}
+			if tt.version != "" {
+				prj.Version = semver.MustParse(tt.version)
+			}
+			prj.loadVCSInfo(&tt.info)
+			if got := prj.Version.String(); got != tt.wantVersion {
+				t.Errorf("version want %s got %s", tt.wantVersion, got)
+			}
+			if !prj.BuildDate.Equal(tt.wantDate) {
+				t.Errorf("build date want %s got %s", tt.wantDate, prj.BuildDate)
+			}
+			if prj.Commit != tt.wantCommit {
+				t.Errorf("commit want %q got %q", tt.wantCommit, prj.Commit)
+			}
+			if !prj.CommitDate.Equal(tt.wantCommitDate) {
+				t.Errorf("commit date want %s got %s", tt.wantCommitDate, prj.CommitDate)
+			}
+			if prj.Dirty != tt.wantDirty {
+				t.Errorf("dirty want %t got %t", tt.wantDirty, prj.Dirty)
+			}
+			if prj.GoVersion != tt.wantGo {
+				t.Errorf("go version want %s got %s", tt.wantGo, prj.GoVersion)
+			}
+		})
+	}
+}
@@ -6,7 +6,8 @@ package project
 
 // Config of the application
 type Config struct {
-	LogLevel int    `json:"loglevel,omitempty"`
-	Color    string `json:"color,omitempty"`
-	InitTerm bool   `json:"initterm,omitempty"`
+	LogLevel  int    `json:"loglevel,omitempty"`
+	LogFormat string `json:"logformat,omitempty"` // text (default), json or logfmt
+	Color     string `json:"color,omitempty"`
+	InitTerm  bool   `json:"initterm,omitempty"`
 }
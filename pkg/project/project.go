@@ -19,6 +19,7 @@ func New(config []byte) (*Project, error) {
 	prj := &Project{}
 	prj.errors = errors.NewMultiError()
 	prj.load(config)
+	prj.loadBuildInfo()
 	if !prj.errors.Nil() {
 		return prj, prj.errors.AsError()
 	}
@@ -37,7 +38,11 @@ type Project struct {
 	License         string              `json:"license,omitempty"`
 	Author          emailaddr.Address   `json:"author,omitempty"`
 	Copyright       Copyright           `json:"copyright,omitempty"`
-	BuildDate       time.Time           `json:"builddate,omitempty"`
+	BuildDate       time.Time           `json:"builddate"`
+	CommitDate      time.Time           `json:"commitdate"`
+	Commit          string              `json:"commit,omitempty"`
+	Dirty           bool                `json:"dirty,omitempty"`
+	GoVersion       string              `json:"goversion,omitempty"`
 	Description     string              `json:"description,omitempty"`
 	Keywords        []string            `json:"keywords,omitempty"`
 	Homepage        string              `json:"homepage,omitempty"`